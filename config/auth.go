@@ -1,20 +1,81 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
 	"os"
+	"time"
 )
 
+// defaultJWTSecret hanya untuk development, ditolak saat startup di mode lain
+const defaultJWTSecret = "secret_key_for_development_only_change_in_production"
+
 // GetJWTSecret mengembalikan secret key untuk JWT
 // Mengambil dari environment variable atau menggunakan default jika tidak ada
 func GetJWTSecret() []byte {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		// Fallback ke default key (seharusnya tidak digunakan di production)
-		secret = "secret_key_for_development_only_change_in_production"
+		secret = defaultJWTSecret
 	}
 	return []byte(secret)
 }
 
+// AppEnv adalah mode aplikasi dari APP_ENV, default "production"
+// Mode development harus dipilih secara eksplisit agar deployment lama tanpa APP_ENV
+// tidak diam-diam memakai secret default
+func AppEnv() string {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "production"
+	}
+	return env
+}
+
+// IsDevelopment mengecek apakah aplikasi berjalan di mode development
+func IsDevelopment() bool {
+	return AppEnv() == "development"
+}
+
+// ValidateJWTSecret menolak secret default di luar mode development
+func ValidateJWTSecret() error {
+	if IsDevelopment() {
+		return nil
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret == "" || secret == defaultJWTSecret {
+		return errors.New("JWT_SECRET wajib diatur dan tidak boleh memakai nilai default di luar mode development")
+	}
+	return nil
+}
+
+// JWTAlgorithm adalah algoritma tanda tangan token: "HS256" (default), "RS256" atau "EdDSA"
+func JWTAlgorithm() string {
+	alg := os.Getenv("JWT_ALG")
+	if alg == "" {
+		alg = "HS256"
+	}
+	return alg
+}
+
+// JWTAcceptLegacyHS256 mengizinkan token HS256 dari JWT_SECRET tetap diterima setelah
+// JWT_ALG diganti ke RS256/EdDSA. Hanya untuk masa migrasi, matikan setelah token lama kedaluwarsa
+func JWTAcceptLegacyHS256() bool {
+	return os.Getenv("JWT_ACCEPT_LEGACY_HS256") == "true"
+}
+
+// JWTKeysDir adalah folder berisi kunci PEM bernama <kid>.pem untuk RS256/EdDSA
+// Kunci privat dipakai untuk tanda tangan, kunci publik saja hanya untuk verifikasi (kunci lama)
+func JWTKeysDir() string {
+	return os.Getenv("JWT_KEYS_DIR")
+}
+
+// JWTActiveKID adalah kid kunci yang dipakai untuk menandatangani token baru
+func JWTActiveKID() string {
+	return os.Getenv("JWT_ACTIVE_KID")
+}
+
 // JWTExpiryTime adalah waktu kedaluwarsa token dalam jam
 func JWTExpiryTime() int {
 	return 24 // 24 jam = 1 hari
@@ -29,4 +90,135 @@ func JWTRefreshExpiryTime() int {
 // Minimal 12 untuk keamanan yang baik
 func BCryptCost() int {
 	return 12
-} 
\ No newline at end of file
+}
+
+// TokenStoreBackend adalah tempat penyimpanan daftar token yang dicabut
+// "postgres" (default) agar bertahan saat restart dan berlaku di semua replika, atau "memory"
+func TokenStoreBackend() string {
+	backend := os.Getenv("TOKEN_STORE")
+	if backend == "" {
+		backend = "postgres"
+	}
+	return backend
+}
+
+// EmailVerificationPolicy menentukan apa yang diblokir sebelum email diverifikasi
+// "off" (default) tidak memblokir apa pun, "login" memblokir login,
+// "write" hanya memblokir endpoint yang mengubah data
+func EmailVerificationPolicy() string {
+	policy := os.Getenv("EMAIL_VERIFICATION_POLICY")
+	if policy == "" {
+		policy = "off"
+	}
+	return policy
+}
+
+// EmailVerificationExpiryTime adalah masa berlaku link verifikasi email dalam jam
+func EmailVerificationExpiryTime() int {
+	return 24
+}
+
+// ValidateEncryptionKey mewajibkan ENCRYPTION_KEY di luar mode development agar data
+// terenkripsi (secret TOTP) tidak ikut hilang saat JWT_SECRET dirotasi
+func ValidateEncryptionKey() error {
+	if IsDevelopment() {
+		return nil
+	}
+	if os.Getenv("ENCRYPTION_KEY") == "" {
+		return errors.New("ENCRYPTION_KEY wajib diatur di luar mode development")
+	}
+	return nil
+}
+
+// EncryptionKey adalah kunci AES-256 untuk mengenkripsi data sensitif di database
+// Diambil dari ENCRYPTION_KEY (base64, 32 byte). Turunan dari JWT secret hanya untuk development
+func EncryptionKey() []byte {
+	if encoded := os.Getenv("ENCRYPTION_KEY"); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			log.Fatal("Error: ENCRYPTION_KEY harus berupa base64 dari 32 byte")
+		}
+		return key
+	}
+	key := sha256.Sum256(append([]byte("encryption-key:"), GetJWTSecret()...))
+	return key[:]
+}
+
+// MFAPendingExpiryTime adalah masa berlaku token mfa_pending dalam menit
+func MFAPendingExpiryTime() int {
+	return 5
+}
+
+// ImpersonationExpiryTime adalah masa berlaku token impersonasi dalam menit
+// Sengaja pendek dan tanpa refresh token, admin harus meminta token baru
+func ImpersonationExpiryTime() int {
+	return 15
+}
+
+// TOTPIssuer adalah nama aplikasi yang tampil di aplikasi authenticator
+func TOTPIssuer() string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Final Project API"
+	}
+	return issuer
+}
+
+// PasswordHashAlgorithm adalah algoritma untuk hash password baru: "argon2id" (default) atau "bcrypt"
+func PasswordHashAlgorithm() string {
+	alg := os.Getenv("PASSWORD_HASH_ALG")
+	if alg == "" {
+		alg = "argon2id"
+	}
+	return alg
+}
+
+// Argon2Memory adalah memori argon2id dalam KiB (64 MiB)
+func Argon2Memory() uint32 {
+	return 64 * 1024
+}
+
+// Argon2Time adalah jumlah iterasi argon2id
+func Argon2Time() uint32 {
+	return 3
+}
+
+// Argon2Threads adalah tingkat paralelisme argon2id
+func Argon2Threads() uint8 {
+	return 2
+}
+
+// LoginAttemptStoreBackend adalah tempat penyimpanan penghitung login gagal
+// "postgres" (default) agar dibagi antar instance, atau "memory"
+func LoginAttemptStoreBackend() string {
+	backend := os.Getenv("LOGIN_ATTEMPT_STORE")
+	if backend == "" {
+		backend = "postgres"
+	}
+	return backend
+}
+
+// LoginFailureWindow adalah lama penghitung login gagal disimpan sejak kegagalan terakhir
+func LoginFailureWindow() time.Duration {
+	return time.Hour
+}
+
+// LockoutPolicy aturan penundaan bertahap dan lockout untuk login gagal
+type LockoutPolicy struct {
+	FreeAttempts int           // jumlah kegagalan tanpa jeda
+	MaxFailures  int           // jumlah kegagalan sampai akun/IP terkunci
+	BaseDelay    time.Duration // jeda awal, berlipat ganda setiap kegagalan berikutnya
+	Lockout      time.Duration // lama terkunci setelah MaxFailures
+}
+
+// LoginUserLockout adalah aturan per username
+// 3 percobaan pertama bebas, lalu jeda 1 detik berlipat ganda, terkunci 15 menit setelah 10 kegagalan
+func LoginUserLockout() LockoutPolicy {
+	return LockoutPolicy{FreeAttempts: 3, MaxFailures: 10, BaseDelay: time.Second, Lockout: 15 * time.Minute}
+}
+
+// LoginIPLockout adalah aturan per alamat IP, lebih longgar karena satu IP
+// bisa dipakai banyak user (NAT/kantor)
+func LoginIPLockout() LockoutPolicy {
+	return LockoutPolicy{FreeAttempts: 20, MaxFailures: 100, BaseDelay: time.Second, Lockout: 15 * time.Minute}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// CacheBackend adalah tempat penyimpanan cache respons
+// "memory" (default) per instance, atau "redis" agar cache dibagi ke semua replika
+func CacheBackend() string {
+	backend := os.Getenv("CACHE_STORE")
+	if backend == "" {
+		backend = "memory"
+	}
+	return backend
+}
+
+// CacheMaxItems adalah jumlah maksimum respons di cache memori, default 1000
+func CacheMaxItems() int {
+	items, err := strconv.Atoi(os.Getenv("CACHE_MAX_ITEMS"))
+	if err != nil || items <= 0 {
+		return 1000
+	}
+	return items
+}
+
+// CacheMaxBytes adalah ukuran maksimum cache memori dalam byte, default 64 MiB
+// Untuk backend redis batas memori diatur lewat maxmemory di Redis
+func CacheMaxBytes() int64 {
+	size, err := strconv.ParseInt(os.Getenv("CACHE_MAX_BYTES"), 10, 64)
+	if err != nil || size <= 0 {
+		return 64 << 20
+	}
+	return size
+}
+
+// TokenCacheMaxEntries adalah jumlah maksimum token di cache verifikasi JWT, default 10000
+// Token valid dan tidak valid berbagi batas yang sama
+func TokenCacheMaxEntries() int {
+	entries, err := strconv.Atoi(os.Getenv("TOKEN_CACHE_MAX_ENTRIES"))
+	if err != nil || entries <= 0 {
+		return 10000
+	}
+	return entries
+}
+
+// TokenCacheInvalidTTL adalah lama token yang gagal diverifikasi langsung ditolak tanpa dicek ulang
+func TokenCacheInvalidTTL() time.Duration {
+	return 10 * time.Minute
+}
@@ -30,7 +30,7 @@ func ConnectDatabase() {
 	DB = db
 
 	// Migrasi model ke database
-	if err := DB.AutoMigrate(&models.User{}, &models.Post{}); err != nil {
+	if err := DB.AutoMigrate(&models.User{}, &models.Post{}, &models.RevokedToken{}, &models.TokenFamily{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.UsageRecord{}, &models.APIKey{}, &models.Identity{}, &models.OAuthState{}, &models.ImpersonationAudit{}); err != nil {
 		log.Fatalf("Gagal melakukan migrasi database: %v", err)
 	}
 
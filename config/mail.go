@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// MailDriver adalah cara pengiriman email: "smtp", "file" atau "log" (default)
+// Driver "log" ditolak saat startup di luar mode development
+func MailDriver() string {
+	driver := os.Getenv("MAIL_DRIVER")
+	if driver == "" {
+		driver = "log"
+	}
+	return driver
+}
+
+// MailFrom adalah alamat pengirim email
+func MailFrom() string {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@example.com"
+	}
+	return from
+}
+
+// MailFilePath adalah file tujuan email untuk driver "file"
+func MailFilePath() string {
+	path := os.Getenv("MAIL_FILE_PATH")
+	if path == "" {
+		path = "mail.log"
+	}
+	return path
+}
+
+// SMTPHost adalah host server SMTP
+func SMTPHost() string {
+	return os.Getenv("SMTP_HOST")
+}
+
+// SMTPPort adalah port server SMTP, default 587
+func SMTPPort() int {
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		return 587
+	}
+	return port
+}
+
+// SMTPUsername adalah username untuk autentikasi SMTP
+func SMTPUsername() string {
+	return os.Getenv("SMTP_USERNAME")
+}
+
+// SMTPPassword adalah password untuk autentikasi SMTP
+func SMTPPassword() string {
+	return os.Getenv("SMTP_PASSWORD")
+}
+
+// AppBaseURL adalah URL frontend yang dipakai untuk membuat link di email
+func AppBaseURL() string {
+	url := os.Getenv("APP_BASE_URL")
+	if url == "" {
+		url = "http://localhost:8080"
+	}
+	return url
+}
+
+// PasswordResetExpiry adalah masa berlaku token reset password dalam menit
+func PasswordResetExpiry() int {
+	return 60
+}
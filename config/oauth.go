@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// OAuthProviderConfig adalah pengaturan satu penyedia login OpenID Connect
+type OAuthProviderConfig struct {
+	// Name dipakai di URL, contoh /auth/google/login
+	Name string
+	// Issuer adalah URL issuer OIDC, endpoint lain ditemukan lewat /.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL adalah URL callback API ini yang didaftarkan di penyedia
+	RedirectURL string
+	Scopes      []string
+}
+
+// OAuthProviders membaca penyedia dari OAUTH_PROVIDERS (dipisah koma, contoh "google,gitlab")
+// Setiap penyedia diatur lewat OAUTH_<NAMA>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL
+// dan _SCOPES (opsional, dipisah spasi, default "openid email profile")
+// Penyedia tanpa issuer atau client ID dilewati
+func OAuthProviders() []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		provider := OAuthProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			continue
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// OAuthStateExpiryTime adalah batas waktu antara mulai login dan callback dari penyedia
+func OAuthStateExpiryTime() time.Duration {
+	return 10 * time.Minute
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// RateLimitPolicy aturan token bucket untuk satu grup route
+// Rate adalah jumlah request yang diisi ulang per Period, Burst adalah kapasitas bucket
+// Name dipakai sebagai prefix kunci agar bucket antar grup tidak tercampur di store bersama
+type RateLimitPolicy struct {
+	Name   string
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// RateLimitGlobal adalah batas per IP untuk semua request
+// 15 request per detik (900/menit) dengan burst 150. Harus di atas kuota role tertinggi
+// (utils.QuotaFor) karena dicek lebih dulu, jika tidak tier premium/admin tidak pernah tercapai
+func RateLimitGlobal() RateLimitPolicy {
+	return RateLimitPolicy{Name: "global", Rate: 15, Period: time.Second, Burst: 150}
+}
+
+// RateLimitAuth adalah batas per IP untuk endpoint login, register dan reset password
+// Lebih ketat karena endpoint ini menjadi sasaran brute force dan spam akun
+func RateLimitAuth() RateLimitPolicy {
+	return RateLimitPolicy{Name: "auth", Rate: 10, Period: time.Minute, Burst: 5}
+}
+
+// RateLimitMaxKeys adalah jumlah maksimum bucket yang disimpan di memori per limiter
+// Bucket yang paling lama tidak dipakai dibuang lebih dulu (LRU)
+func RateLimitMaxKeys() int {
+	return 10000
+}
+
+// RateLimitBackend adalah tempat penyimpanan bucket rate limit
+// "memory" (default) per instance, atau "redis" agar kuota dibagi ke semua replika
+func RateLimitBackend() string {
+	backend := os.Getenv("RATE_LIMIT_STORE")
+	if backend == "" {
+		backend = "memory"
+	}
+	return backend
+}
+
+// RateLimitRedisTimeout adalah batas waktu satu pengecekan ke Redis
+// Jika lewat, request dibatasi oleh limiter lokal
+func RateLimitRedisTimeout() time.Duration {
+	return 100 * time.Millisecond
+}
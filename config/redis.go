@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis adalah client Redis bersama, nil jika REDIS_URL tidak diatur
+var Redis *redis.Client
+
+// RedisURL adalah alamat Redis, contoh redis://localhost:6379/0
+func RedisURL() string {
+	return os.Getenv("REDIS_URL")
+}
+
+// ConnectRedis membuat client Redis dari REDIS_URL
+// Redis yang belum bisa dihubungi tidak menghentikan aplikasi karena fitur yang
+// memakainya tetap berjalan secara lokal sampai Redis tersedia
+func ConnectRedis() {
+	url := RedisURL()
+	if url == "" {
+		log.Fatal("Error: REDIS_URL wajib diatur untuk backend redis")
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		log.Fatalf("REDIS_URL tidak valid: %v", err)
+	}
+	Redis = redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := Redis.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Redis belum bisa dihubungi: %v", err)
+		return
+	}
+	log.Println("Berhasil terhubung ke Redis")
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"final/config"
+	"final/models"
+	"final/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyResponse menampilkan API key tanpa hash, scope sebagai daftar
+func apiKeyResponse(key models.APIKey) gin.H {
+	return gin.H{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       utils.ParseScopes(key.Scopes),
+		"expires_at":   key.ExpiresAt,
+		"last_used_at": key.LastUsedAt,
+		"last_used_ip": key.LastUsedIP,
+		"revoked_at":   key.RevokedAt,
+		"created_at":   key.CreatedAt,
+	}
+}
+
+// findMyAPIKey mengambil API key milik user berdasarkan parameter id
+// Key milik user lain dianggap tidak ada. Jika gagal, response sudah dikirim
+func findMyAPIKey(c *gin.Context, user models.User) (models.APIKey, bool) {
+	var key models.APIKey
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&key).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return key, false
+	}
+	return key, true
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a long-lived API key for machine clients. Scopes must be permissions of the user's role. The key is only shown once in this response; send it as `X-API-Key: <key>` or `Authorization: Bearer <key>`.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body docs.CreateAPIKeyRequest true "Name, scopes and optional expiry"
+// @Success 201 {object} docs.APIKeyCreatedResponse "API key created"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - validation error or scope not allowed"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage API keys"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var input struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required,min=1"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	scopes, err := utils.ValidateScopes(user.Role, input.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rawKey, err := utils.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	key := models.APIKey{
+		UserID:  user.ID,
+		Name:    input.Name,
+		Prefix:  utils.APIKeyDisplayPrefix(rawKey),
+		KeyHash: utils.HashToken(rawKey),
+		Scopes:  utils.FormatScopes(scopes),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := config.DB.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save API key"})
+		return
+	}
+
+	// Key asli hanya dikirim sekali, setelah ini hanya prefix yang bisa dilihat
+	response := apiKeyResponse(key)
+	response["key"] = rawKey
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys godoc
+// @Summary List my API keys
+// @Description List the API keys of the logged in user, including revoked ones. The key itself is never returned.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} docs.APIKeyResponse "API keys"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage API keys"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var keys []models.APIKey
+	if err := config.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API keys"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateAPIKey godoc
+// @Summary Rename or rescope an API key
+// @Description Change the name and/or scopes of an active API key. Scopes replace the current list.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Param request body docs.UpdateAPIKeyRequest true "New name and/or scopes"
+// @Success 200 {object} docs.APIKeyResponse "API key updated"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - validation error, scope not allowed or key revoked"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage API keys"
+// @Failure 404 {object} docs.ErrorResponse "API key not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/api-keys/{id} [patch]
+func UpdateAPIKey(c *gin.Context) {
+	var input struct {
+		Name   string   `json:"name" binding:"max=100"`
+		Scopes []string `json:"scopes" binding:"omitempty,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	key, ok := findMyAPIKey(c, user)
+	if !ok {
+		return
+	}
+	if key.RevokedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API key has been revoked"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != "" {
+		updates["name"] = input.Name
+	}
+	if input.Scopes != nil {
+		scopes, err := utils.ValidateScopes(user.Role, input.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["scopes"] = utils.FormatScopes(scopes)
+	}
+
+	if err := config.DB.Model(&key).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKeyResponse(key))
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key. Requests using it are rejected immediately.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]string "API key revoked"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage API keys"
+// @Failure 404 {object} docs.ErrorResponse "API key not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	key, ok := findMyAPIKey(c, user)
+	if !ok {
+		return
+	}
+
+	// Mencabut ulang key yang sudah dicabut tidak mengubah waktu pencabutan
+	if key.RevokedAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&key).Update("revoked_at", &now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"final/middleware"
+	"final/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiKeyRouter returns a router with API key management behind AuthenticateAs
+// and a post route behind the real AuthMiddleware so keys can be used
+func apiKeyRouter(t *testing.T) (*gin.Engine, func(payload interface{}) *httptest.ResponseRecorder) {
+	user := CreateTestUser(t)
+
+	r := SetupTestRouter()
+	r.POST("/me/api-keys", AuthenticateAs(user), CreateAPIKey)
+	r.GET("/me/api-keys", AuthenticateAs(user), ListAPIKeys)
+	r.DELETE("/me/api-keys/:id", AuthenticateAs(user), RevokeAPIKey)
+	r.POST("/posts", middleware.AuthMiddleware(), middleware.RequirePermission(utils.PermPostsWrite), CreatePost)
+	r.GET("/users", middleware.AuthMiddleware(), middleware.RequirePermission(utils.PermUsersRead), GetUsers)
+
+	create := func(payload interface{}) *httptest.ResponseRecorder {
+		return postJSON(r, "/me/api-keys", payload)
+	}
+	return r, create
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		r, create := apiKeyRouter(t)
+
+		resp := create(map[string]interface{}{"name": "ci", "scopes": []string{"posts:write"}})
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		var created struct {
+			ID     uint     `json:"id"`
+			Key    string   `json:"key"`
+			Prefix string   `json:"prefix"`
+			Scopes []string `json:"scopes"`
+		}
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		assert.True(t, utils.IsAPIKey(created.Key))
+		assert.Equal(t, []string{"posts:write"}, created.Scopes)
+
+		withKey := func(method, path string, header string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(map[string]string{"title": "From CI", "body": "Body"})
+			req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if header == "X-API-Key" {
+				req.Header.Set("X-API-Key", created.Key)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+created.Key)
+			}
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp
+		}
+
+		// Both header schemes are accepted, scopes limit what the key can do
+		assert.Equal(t, http.StatusCreated, withKey(http.MethodPost, "/posts", "X-API-Key").Code)
+		assert.Equal(t, http.StatusCreated, withKey(http.MethodPost, "/posts", "Authorization").Code)
+		assert.Equal(t, http.StatusForbidden, withKey(http.MethodGet, "/users", "X-API-Key").Code)
+
+		// The key is never listed again, only its prefix and last use
+		req, _ := http.NewRequest(http.MethodGet, "/me/api-keys", nil)
+		list := httptest.NewRecorder()
+		r.ServeHTTP(list, req)
+		assert.Equal(t, http.StatusOK, list.Code)
+		assert.NotContains(t, list.Body.String(), created.Key)
+		assert.Contains(t, list.Body.String(), created.Prefix)
+		assert.Contains(t, list.Body.String(), `"last_used_ip"`)
+
+		req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/me/api-keys/%d", created.ID), nil)
+		revoke := httptest.NewRecorder()
+		r.ServeHTTP(revoke, req)
+		assert.Equal(t, http.StatusOK, revoke.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, withKey(http.MethodPost, "/posts", "X-API-Key").Code)
+	})
+}
+
+func TestCreateAPIKeyRejectsScopeOutsideRole(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		_, create := apiKeyRouter(t)
+
+		resp := create(map[string]interface{}{"name": "ci", "scopes": []string{"cache:manage"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = create(map[string]interface{}{"name": "ci", "scopes": []string{}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -1,12 +1,18 @@
 package controllers
 
 import (
+	"errors"
 	"final/config"
 	"final/models"
 	"final/utils"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // Register godoc
@@ -88,6 +94,13 @@ func Register(c *gin.Context) {
 		})
 		return
 	}
+	invalidateUser(user.ID)
+
+	// Kirim link verifikasi email, kegagalan tidak membatalkan registrasi
+	// karena user masih bisa meminta link baru lewat /verify-email/resend
+	if err := sendVerificationEmail(user); err != nil {
+		log.Printf("Gagal mengirim email verifikasi ke user %d: %v", user.ID, err)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  http.StatusCreated,
@@ -103,14 +116,17 @@ func Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return JWT token. When 2FA is enabled, returns an mfa_token to be exchanged at /login/mfa instead.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body docs.LoginRequest true "User login credentials"
 // @Success 200 {object} docs.TokenResponse "Login successful"
+// @Success 202 {object} docs.MFARequiredResponse "Password accepted, second factor required"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid credentials"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - email not verified"
+// @Failure 429 {object} docs.ErrorResponse "Too many failed attempts, see Retry-After header"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /login [post]
 func Login(c *gin.Context) {
@@ -124,21 +140,73 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Tolak lebih awal jika username atau IP sedang ditunda/terkunci
+	if !checkLoginThrottle(c, input.Username) {
+		return
+	}
+
 	// Cari user di database
 	var user models.User
 	if err := config.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
+		recordLoginFailure(c, input.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
 	// Cek password
 	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		recordLoginFailure(c, input.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	// Generate token JWT (access + refresh)
-	tokens, err := utils.GenerateJWT(user.Username)
+	// Upgrade hash lama secara transparan selagi password asli tersedia
+	if utils.NeedsRehash(user.Password) {
+		if hashedPassword, err := utils.HashPassword(input.Password); err != nil {
+			log.Printf("Gagal membuat ulang hash password user %d: %v", user.ID, err)
+		} else if err := config.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			log.Printf("Gagal menyimpan hash password baru user %d: %v", user.ID, err)
+		} else {
+			invalidateUser(user.ID)
+		}
+	}
+
+	// Blokir login sampai email diverifikasi jika kebijakan mengharuskan
+	if config.EmailVerificationPolicy() == "login" && user.EmailVerifiedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
+		return
+	}
+
+	// Password benar, penghitung username dimulai dari nol lagi
+	// Untuk akun dengan 2FA, penghitung direset setelah kode kedua benar
+	if !user.TOTPEnabled {
+		if err := utils.ResetLoginFailures(user.Username); err != nil {
+			log.Printf("Gagal mereset penghitung login user %d: %v", user.ID, err)
+		}
+	}
+
+	completeLogin(c, user)
+}
+
+// completeLogin menyelesaikan login user yang sudah terautentikasi (password atau penyedia luar)
+// Jika 2FA aktif, token baru diterbitkan setelah kode diverifikasi di /login/mfa
+func completeLogin(c *gin.Context, user models.User) {
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAPendingToken(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   config.MFAPendingExpiryTime() * 60, // Dalam detik
+		})
+		return
+	}
+
+	// Generate token JWT (access + refresh) dalam token family baru
+	tokens, err := issueTokens(c, user, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -180,22 +248,60 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Ambil username dari claims
-	username, ok := (*claims)["username"].(string)
-	if !ok {
+	// Tolak refresh token yang sudah dicabut (misalnya setelah logout)
+	revoked, err := utils.IsTokenRevoked(claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token status"})
+		return
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+
+	// Ambil user ID dari claim sub, refresh token lama tanpa sub harus login ulang
+	userID, err := utils.TokenUserID(claims)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
 		return
 	}
 
 	// Verifikasi user masih ada di database
 	var user models.User
-	if dbErr := config.DB.Where("username = ?", username).First(&user).Error; dbErr != nil {
+	if dbErr := config.DB.First(&user, userID).Error; dbErr != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
 
-	// Generate token baru
-	tokens, err := utils.GenerateJWT(username)
+	// Token version dinaikkan setelah token ini terbit, semua token user sudah dicabut
+	if utils.TokenVersion(claims) != user.TokenVersion {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+
+	// Tandai refresh token sudah dipakai, pemakaian ulang mencabut seluruh family
+	family, err := utils.ConsumeRefreshToken(config.DB, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, all sessions in this family have been revoked"})
+		case errors.Is(err, utils.ErrRefreshTokenUnknown):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		case errors.Is(err, utils.ErrTokenFamilyRevoked):
+			// Sesi sudah diakhiri dari perangkat lain atau lewat logout
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token"})
+		}
+		return
+	}
+	if family.UserID != user.ID {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	// Generate token baru dalam family yang sama
+	tokens, err := issueTokens(c, user, family.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -210,17 +316,117 @@ func RefreshToken(c *gin.Context) {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Invalidate user's refresh token
+// @Description Revoke the current access token and, if provided, the refresh token family
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param refresh_token body docs.LogoutRequest false "Refresh token to revoke"
 // @Success 200 {object} map[string]string "Logout successful"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - invalid refresh token"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /logout [post]
 func Logout(c *gin.Context) {
-	// Di implementasi lengkap, kita akan menambahkan token ke blacklist
-	// Tapi untuk sekarang, kita hanya kembalikan sukses
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	// Body bersifat opsional, cukup abaikan jika kosong
+	_ = c.ShouldBindJSON(&input)
+
+	// Ambil claims access token yang sudah divalidasi oleh middleware auth
+	value, exists := c.Get("claims")
+	accessClaims, ok := value.(*jwt.MapClaims)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	// Cabut refresh token jika dikirim, hanya boleh milik user yang sama
+	if input.RefreshToken != "" {
+		refreshClaims, err := utils.ParseRefreshToken(input.RefreshToken)
+		if err != nil || (*refreshClaims)["sub"] != (*accessClaims)["sub"] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		// Cabut seluruh family sehingga refresh token turunannya juga tidak berlaku
+		familyID, _ := (*refreshClaims)["fam"].(string)
+		if err := utils.RevokeTokenFamily(config.DB, familyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke refresh token"})
+			return
+		}
+	}
+
+	// Cabut access token yang sedang digunakan
+	if err := utils.RevokeToken(accessClaims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access token"})
+		return
+	}
+	utils.InvalidateCachedToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully logged out"})
 }
+
+// issueTokens menerbitkan pasangan access + refresh token untuk user
+// familyID kosong berarti login baru sehingga dibuat token family (sesi) baru
+// dengan user agent dan IP dari request
+func issueTokens(c *gin.Context, user models.User, familyID string) (*utils.TokenDetails, error) {
+	if familyID == "" {
+		family, err := utils.CreateTokenFamily(config.DB, user.ID, utils.SessionInfo{
+			UserAgent: c.Request.UserAgent(),
+			IP:        c.ClientIP(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		familyID = family.ID
+	}
+
+	tokens, err := utils.GenerateJWT(utils.TokenSubject{
+		UserID:       user.ID,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		FamilyID:     familyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.RecordRefreshToken(config.DB, familyID, tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// checkLoginThrottle menolak percobaan login selama username atau IP masih ditunda/terkunci
+// Jika ditolak, response 429 dengan header Retry-After sudah dikirim
+func checkLoginThrottle(c *gin.Context, username string) bool {
+	wait, err := utils.LoginRetryAfter(username, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+		return false
+	}
+	if wait > 0 {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many failed login attempts",
+			"retry_after": retryAfter,
+		})
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure mencatat login gagal dan memberi tahu klien kapan boleh mencoba lagi
+func recordLoginFailure(c *gin.Context, username string) {
+	wait, err := utils.RecordLoginFailure(username, c.ClientIP())
+	if err != nil {
+		log.Printf("Gagal mencatat login gagal untuk %q: %v", username, err)
+		return
+	}
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"final/models"
+	"final/utils"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // TestRegister tests the Register endpoint
@@ -87,4 +90,80 @@ func TestLogin(t *testing.T) {
 		assert.Contains(t, resp.Body.String(), "access_token")
 		assert.Contains(t, resp.Body.String(), "refresh_token")
 	})
-}
\ No newline at end of file
+}
+// loginTestUser logs in the default test user and returns the token response
+func loginTestUser(t *testing.T, r http.Handler, username string) map[string]interface{} {
+	jsonValue, _ := json.Marshal(map[string]string{
+		"username": username,
+		"password": "password123",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(resp.Body.Bytes(), &body)
+	return body
+}
+
+// refreshWith performs a /refresh request with the given refresh token
+func refreshWith(r http.Handler, refreshToken string) *httptest.ResponseRecorder {
+	jsonValue, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	req, _ := http.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		r.POST("/refresh", RefreshToken)
+
+		tokens := loginTestUser(t, r, testUser.Username)
+		original := tokens["refresh_token"].(string)
+
+		// First use rotates the token
+		resp := refreshWith(r, original)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var rotated map[string]interface{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &rotated)
+		assert.NotEqual(t, original, rotated["refresh_token"])
+
+		// Replaying the consumed token is rejected and revokes the family
+		resp = refreshWith(r, original)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), "reuse detected")
+
+		// The rotated token belongs to the revoked family and no longer works
+		resp = refreshWith(r, rotated["refresh_token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		// Store the password in the legacy salt:hash layout
+		salt := "c2FsdHNhbHRzYWx0c2FsdA=="
+		legacy, _ := bcrypt.GenerateFromPassword([]byte("password123"+salt), bcrypt.MinCost)
+		testDB.Model(&testUser).Update("password", salt+":"+string(legacy))
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		loginTestUser(t, r, testUser.Username)
+
+		var upgraded models.User
+		testDB.First(&upgraded, testUser.ID)
+		assert.True(t, strings.HasPrefix(upgraded.Password, "$argon2id$"))
+		assert.True(t, utils.CheckPasswordHash("password123", upgraded.Password))
+	})
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"final/models"
+	"final/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tagCache menandai respons dengan tag resource agar cache-nya bisa dihapus saat resource berubah
+func tagCache(c *gin.Context, tags ...string) {
+	c.Set(utils.CacheTagsKey, append(c.GetStringSlice(utils.CacheTagsKey), tags...))
+}
+
+// tagPosts menandai respons yang berisi post beserta penulisnya
+func tagPosts(c *gin.Context, posts ...models.Post) {
+	tags := make([]string, 0, len(posts)*2)
+	for _, post := range posts {
+		tags = append(tags, utils.PostTag(post.ID), utils.UserTag(post.UserID))
+	}
+	tagCache(c, tags...)
+}
+
+// tagUsers menandai respons yang berisi user beserta post-nya
+func tagUsers(c *gin.Context, users ...models.User) {
+	tags := make([]string, 0, len(users))
+	for _, user := range users {
+		tags = append(tags, utils.UserTag(user.ID))
+		tagPosts(c, user.Posts...)
+	}
+	tagCache(c, tags...)
+}
+
+// invalidatePost menghapus cache post beserta daftar post dan data penulisnya
+func invalidatePost(post models.Post) {
+	utils.InvalidateCacheTags(utils.PostTag(post.ID), utils.TagPostsList, utils.UserTag(post.UserID))
+}
+
+// invalidateUser menghapus cache user, termasuk daftar user dan post yang menampilkan user tersebut
+func invalidateUser(userID uint) {
+	utils.InvalidateCacheTags(utils.UserTag(userID), utils.TagUsersList)
+}
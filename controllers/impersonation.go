@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"final/config"
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonateUser godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token that acts as the user, for reproducing issues. The token carries an `act` claim with the admin, has no refresh token and cannot be used for account management (password, 2FA, API keys, sessions, deletion). Every request made with it is recorded in the impersonation audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body docs.ImpersonateRequest true "Reason for the impersonation"
+// @Success 201 {object} docs.ImpersonationResponse "Impersonation token issued"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - reason missing or impersonating yourself"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - not allowed to impersonate this user"
+// @Failure 404 {object} docs.ErrorResponse "User not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/impersonate [post]
+func ImpersonateUser(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason" binding:"required,max=255"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var target models.User
+	if err := config.DB.First(&target, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if target.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot impersonate yourself"})
+		return
+	}
+	if !utils.CanImpersonate(admin, target) {
+		forbidden(c)
+		return
+	}
+
+	td, err := utils.GenerateImpersonationToken(admin, target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	// Token hanya diberikan jika awal impersonasi berhasil dicatat
+	if err := config.DB.Create(&models.ImpersonationAudit{
+		AdminID: admin.ID,
+		UserID:  target.ID,
+		TokenID: td.AccessUUID,
+		Action:  utils.ImpersonationStart,
+		Reason:  input.Reason,
+		Method:  c.Request.Method,
+		Path:    c.Request.URL.Path,
+		Status:  http.StatusCreated,
+		IP:      c.ClientIP(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impersonation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"access_token": td.AccessToken,
+		"token_id":     td.AccessUUID,
+		"expires_in":   td.AtExpires - time.Now().Unix(),
+		"impersonating": gin.H{
+			"id":       target.ID,
+			"username": target.Username,
+		},
+	})
+}
+
+// StopImpersonation godoc
+// @Summary Stop impersonating
+// @Description End the impersonation before the token expires. Must be called with the impersonation token itself; the token is revoked and an "end" entry is written to the audit log.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Impersonation stopped"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - not an impersonation token"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /impersonation/stop [post]
+func StopImpersonation(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+	if !user.IsImpersonated() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This token is not an impersonation token"})
+		return
+	}
+
+	// Cabut token impersonasi agar tidak bisa dipakai lagi sampai kedaluwarsa
+	if err := utils.RevokeToken(user.Claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke impersonation token"})
+		return
+	}
+	utils.InvalidateCachedToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+
+	tokenID, _ := (*user.Claims)["jti"].(string)
+	if err := config.DB.Create(&models.ImpersonationAudit{
+		AdminID: user.Impersonator.ID,
+		UserID:  user.ID,
+		TokenID: tokenID,
+		Action:  utils.ImpersonationEnd,
+		Method:  c.Request.Method,
+		Path:    c.Request.URL.Path,
+		Status:  http.StatusOK,
+		IP:      c.ClientIP(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impersonation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation stopped"})
+}
+
+// ListImpersonationAudits godoc
+// @Summary List impersonation audit log
+// @Description List impersonation starts and ends and every request made with an impersonation token, newest first. Filter by admin_id, user_id or token_id.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param admin_id query int false "Real admin ID"
+// @Param user_id query int false "Impersonated user ID"
+// @Param token_id query string false "Impersonation token ID (jti)"
+// @Param limit query int false "Maximum number of entries (default 100, max 500)"
+// @Success 200 {array} docs.ImpersonationAuditResponse "Audit entries"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - invalid filter"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - admin only"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /admin/impersonations [get]
+func ListImpersonationAudits(c *gin.Context) {
+	query := config.DB.Order("created_at DESC, id DESC")
+
+	for _, column := range []string{"admin_id", "user_id"} {
+		value := c.Query(column)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + column})
+			return
+		}
+		query = query.Where(column+" = ?", id)
+	}
+	if tokenID := c.Query("token_id"); tokenID != "" {
+		query = query.Where("token_id = ?", tokenID)
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	var entries []models.ImpersonationAudit
+	if err := query.Limit(limit).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
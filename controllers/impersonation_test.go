@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final/config"
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonateUser(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		user := CreateTestUser(t)
+		admin := CreateTestUserWithRole(t, "admin", "admin@example.com", utils.RoleAdmin)
+		otherAdmin := CreateTestUserWithRole(t, "admin2", "admin2@example.com", utils.RoleAdmin)
+
+		r := SetupTestRouter()
+		r.POST("/admin/users/:id/impersonate", AuthenticateAs(admin), ImpersonateUser)
+		r.GET("/me/usage", middleware.AuthMiddleware(), GetMyUsage)
+		r.DELETE("/users/:id", middleware.AuthMiddleware(), middleware.RequireSession(), DeleteUser)
+		r.POST("/impersonation/stop", middleware.AuthMiddleware(), StopImpersonation)
+
+		// A reason is required, admins cannot impersonate themselves or other admins
+		assert.Equal(t, http.StatusBadRequest, postJSON(r, fmt.Sprintf("/admin/users/%d/impersonate", user.ID), map[string]string{}).Code)
+		assert.Equal(t, http.StatusBadRequest, postJSON(r, fmt.Sprintf("/admin/users/%d/impersonate", admin.ID), map[string]string{"reason": "test"}).Code)
+		assert.Equal(t, http.StatusForbidden, postJSON(r, fmt.Sprintf("/admin/users/%d/impersonate", otherAdmin.ID), map[string]string{"reason": "test"}).Code)
+		assert.Equal(t, http.StatusNotFound, postJSON(r, "/admin/users/999999/impersonate", map[string]string{"reason": "test"}).Code)
+
+		resp := postJSON(r, fmt.Sprintf("/admin/users/%d/impersonate", user.ID), map[string]string{"reason": "ticket #1"})
+		assert.Equal(t, http.StatusCreated, resp.Code)
+
+		var body struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+			TokenID      string `json:"token_id"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NotEmpty(t, body.AccessToken)
+		assert.Empty(t, body.RefreshToken)
+
+		send := func(method, path string) int {
+			req, _ := http.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+body.AccessToken)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/me/usage"))
+		assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, fmt.Sprintf("/users/%d", user.ID)))
+
+		// Stopping revokes the token before it expires
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, "/impersonation/stop"))
+		assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/me/usage"))
+
+		var entries []models.ImpersonationAudit
+		config.DB.Where("token_id = ?", body.TokenID).Order("id").Find(&entries)
+		if assert.Len(t, entries, 5) {
+			assert.Equal(t, utils.ImpersonationStart, entries[0].Action)
+			assert.Equal(t, "ticket #1", entries[0].Reason)
+			for _, entry := range entries {
+				assert.Equal(t, admin.ID, entry.AdminID)
+				assert.Equal(t, user.ID, entry.UserID)
+			}
+			assert.Equal(t, http.StatusOK, entries[1].Status)
+			assert.Equal(t, http.StatusForbidden, entries[2].Status)
+			assert.Equal(t, utils.ImpersonationEnd, entries[4].Action)
+		}
+
+		// The user still exists
+		assert.Nil(t, config.DB.First(&models.User{}, user.ID).Error)
+	})
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"final/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys used to verify tokens issued by this API, including keys that were rotated out but may still sign live tokens
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "JWK set"
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.GetKeyring().JWKS())
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"final/config"
+	"final/models"
+	"final/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUser godoc
+// @Summary Unlock a user account
+// @Description Clear the failed login counter of a user so they can log in again immediately. Optionally also clear the counter of an IP address.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body docs.UnlockUserRequest false "Optional IP address to unlock"
+// @Success 200 {object} map[string]string "Account unlocked"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - admin only"
+// @Failure 404 {object} docs.ErrorResponse "User not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	var input struct {
+		IP string `json:"ip"`
+	}
+	// Body opsional, body kosong tetap diterima
+	_ = c.ShouldBindJSON(&input)
+
+	var user models.User
+	if err := config.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := utils.ResetLoginFailures(user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+	if input.IP != "" {
+		if err := utils.ResetIPLoginFailures(input.IP); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock IP address"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
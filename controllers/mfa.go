@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"final/config"
+	"final/models"
+	"final/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Jumlah kode pemulihan yang dibuat saat enrollment 2FA
+const recoveryCodeCount = 10
+
+// verifyTOTP mengecek kode TOTP user dan menolak kode yang sudah pernah dipakai
+func verifyTOTP(user models.User, code string) (bool, error) {
+	secret, err := utils.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// Update bersyarat agar kode yang sama tidak bisa dipakai dua kali
+	result := config.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// verifySecondFactor menerima kode TOTP atau salah satu kode pemulihan yang belum dipakai
+// Kode pemulihan tetap dicek jika secret TOTP gagal dibuka (misalnya kunci enkripsi berubah)
+func verifySecondFactor(user models.User, code string) (bool, error) {
+	ok, err := verifyTOTP(user, code)
+	if err != nil {
+		log.Printf("Gagal memverifikasi TOTP user %d: %v", user.ID, err)
+	} else if ok {
+		return true, nil
+	}
+
+	now := time.Now()
+	result := config.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code))).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a new TOTP secret and recovery codes. 2FA is not active until confirmed through /2fa/activate.
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} docs.TOTPEnrollResponse "otpauth URI, secret and recovery codes"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - 2FA already enabled"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /2fa/enroll [post]
+func EnrollTOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt secret"})
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":    encrypted,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Kode pemulihan lama tidak berlaku lagi
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		records := make([]models.RecoveryCode, 0, len(codes))
+		for _, code := range codes {
+			records = append(records, models.RecoveryCode{
+				UserID:   user.ID,
+				CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(code)),
+			})
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save 2FA enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"otpauth_uri":    utils.TOTPAuthURI(config.TOTPIssuer(), user.Username, secret),
+		"secret":         secret,
+		"recovery_codes": codes,
+	})
+}
+
+// ActivateTOTP godoc
+// @Summary Activate TOTP
+// @Description Confirm enrollment with a code from the authenticator app to turn on 2FA
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body docs.TOTPCodeRequest true "Current TOTP code"
+// @Success 200 {object} map[string]string "2FA enabled"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - invalid code or no pending enrollment"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /2fa/activate [post]
+func ActivateTOTP(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No pending 2FA enrollment"})
+		return
+	}
+
+	valid, err := verifyTOTP(user, input.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if err := config.DB.Model(&user).Update("totp_enabled", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable 2FA"})
+		return
+	}
+	invalidateUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn off 2FA. Requires the account password and a TOTP or recovery code.
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body docs.TOTPDisableRequest true "Password and TOTP or recovery code"
+// @Success 200 {object} map[string]string "2FA disabled"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - 2FA not enabled"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid password or code"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /2fa/disable [post]
+func DisableTOTP(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	valid, err := verifySecondFactor(user, input.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable 2FA"})
+		return
+	}
+	invalidateUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// LoginMFA godoc
+// @Summary Complete login with 2FA
+// @Description Exchange the mfa_token returned by /login and a TOTP or recovery code for access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body docs.LoginMFARequest true "mfa_token and TOTP or recovery code"
+// @Success 200 {object} docs.TokenResponse "Login successful"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token or code"
+// @Failure 429 {object} docs.ErrorResponse "Too many failed attempts, see Retry-After header"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /login/mfa [post]
+func LoginMFA(c *gin.Context) {
+	var input struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, userID, err := utils.ParseMFAPendingToken(input.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired mfa_token"})
+		return
+	}
+
+	// mfa_token hanya bisa ditukar sekali
+	revoked, err := utils.IsTokenRevoked(claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token status"})
+		return
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired mfa_token"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil || !user.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired mfa_token"})
+		return
+	}
+
+	// Kode 2FA yang salah dihitung sama seperti password yang salah
+	if !checkLoginThrottle(c, user.Username) {
+		return
+	}
+
+	valid, err := verifySecondFactor(user, input.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if !valid {
+		recordLoginFailure(c, user.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if err := utils.ResetLoginFailures(user.Username); err != nil {
+		log.Printf("Gagal mereset penghitung login user %d: %v", user.ID, err)
+	}
+
+	if err := utils.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to consume mfa_token"})
+		return
+	}
+
+	tokens, err := issueTokens(c, user, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    config.JWTExpiryTime() * 3600, // Dalam detik
+	})
+}
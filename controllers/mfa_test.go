@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final/models"
+	"final/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPLoginFlow(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		r.POST("/login/mfa", LoginMFA)
+		r.POST("/2fa/enroll", AuthenticateAs(testUser), EnrollTOTP)
+		r.POST("/2fa/activate", AuthenticateAs(testUser), ActivateTOTP)
+
+		resp := postJSON(r, "/2fa/enroll", nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var enrollment struct {
+			Secret        string   `json:"secret"`
+			RecoveryCodes []string `json:"recovery_codes"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &enrollment)
+		assert.Len(t, enrollment.RecoveryCodes, recoveryCodeCount)
+
+		// The secret is encrypted at rest
+		var stored models.User
+		testDB.First(&stored, testUser.ID)
+		assert.NotEqual(t, enrollment.Secret, stored.TOTPSecret)
+
+		now := time.Now()
+		code, _ := utils.TOTPCode(enrollment.Secret, utils.TOTPStep(now))
+		resp = postJSON(r, "/2fa/activate", map[string]string{"code": code})
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// Login now stops at the second factor
+		resp = postJSON(r, "/login", map[string]string{"username": testUser.Username, "password": "password123"})
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "access_token")
+
+		var pending map[string]interface{}
+		_ = json.Unmarshal(resp.Body.Bytes(), &pending)
+		mfaToken := pending["mfa_token"].(string)
+
+		// The code already used for activation cannot be replayed
+		resp = postJSON(r, "/login/mfa", map[string]string{"mfa_token": mfaToken, "code": code})
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+		// A recovery code works, once
+		resp = postJSON(r, "/login/mfa", map[string]string{"mfa_token": mfaToken, "code": enrollment.RecoveryCodes[0]})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "access_token")
+
+		// The mfa_token is single-use
+		next, _ := utils.TOTPCode(enrollment.Secret, utils.TOTPStep(now)+1)
+		resp = postJSON(r, "/login/mfa", map[string]string{"mfa_token": mfaToken, "code": next})
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+// TestRecoveryCodeWhenTOTPSecretUnreadable tests that recovery codes still work after the encryption key changed
+func TestRecoveryCodeWhenTOTPSecretUnreadable(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+		testUser.TOTPEnabled = true
+		testUser.TOTPSecret = "bm90LWVuY3J5cHRlZC13aXRoLXRoaXMta2V5"
+		testDB.Model(&testUser).Updates(map[string]interface{}{"totp_enabled": true, "totp_secret": testUser.TOTPSecret})
+		testDB.Create(&models.RecoveryCode{UserID: testUser.ID, CodeHash: utils.HashToken(utils.NormalizeRecoveryCode("abcd-efgh"))})
+
+		valid, err := verifySecondFactor(testUser, "123456")
+		assert.Nil(t, err)
+		assert.False(t, valid)
+
+		valid, err = verifySecondFactor(testUser, "abcd-efgh")
+		assert.Nil(t, err)
+		assert.True(t, valid)
+	})
+}
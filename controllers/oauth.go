@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"final/config"
+	"final/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthStateCookie menyimpan state di browser yang memulai login, sehingga callback
+// dari browser lain (login CSRF) ditolak walaupun state-nya valid
+const oauthStateCookie = "oauth_state"
+
+// setOAuthStateCookie mengatur atau menghapus (maxAge -1) cookie state untuk satu penyedia
+func setOAuthStateCookie(c *gin.Context, provider, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, maxAge, "/auth/"+provider, "", !config.IsDevelopment(), true)
+}
+
+// ListOAuthProviders godoc
+// @Summary List login providers
+// @Description Names of the configured external login providers, for use in /auth/{provider}/login
+// @Tags auth
+// @Produce json
+// @Success 200 {object} docs.OAuthProvidersResponse "Configured providers"
+// @Router /auth/providers [get]
+func ListOAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": utils.OAuthProviderNames()})
+}
+
+// OAuthLogin godoc
+// @Summary Start login with an external provider
+// @Description Redirects the browser to the provider's login page using the authorization code flow with PKCE. The provider redirects back to /auth/{provider}/callback.
+// @Tags auth
+// @Param provider path string true "Provider name" example(google)
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} docs.ErrorResponse "Unknown provider"
+// @Failure 502 {object} docs.ErrorResponse "Provider unavailable"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /auth/{provider}/login [get]
+func OAuthLogin(c *gin.Context) {
+	provider, ok := utils.GetOAuthProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	state, err := utils.CreateOAuthState(config.DB, provider.Name())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.CodeChallenge)
+	if err != nil {
+		log.Printf("Gagal menghubungi penyedia login %s: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider unavailable"})
+		return
+	}
+
+	setOAuthStateCookie(c, provider.Name(), state.State, int(config.OAuthStateExpiryTime().Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallback godoc
+// @Summary Finish login with an external provider
+// @Description Exchanges the authorization code for a verified identity and logs in the linked user. On first login the account is linked by verified email, or a new user is created. Returns tokens like /login, or an mfa_token when 2FA is enabled.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name" example(google)
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from /auth/{provider}/login"
+// @Success 200 {object} docs.TokenResponse "Login successful"
+// @Success 202 {object} docs.MFARequiredResponse "Identity verified, second factor required"
+// @Failure 400 {object} docs.ErrorResponse "Invalid, expired or reused state, or login denied at the provider"
+// @Failure 401 {object} docs.ErrorResponse "Provider rejected the code or returned an invalid ID token"
+// @Failure 403 {object} docs.ErrorResponse "Provider did not verify the email address"
+// @Failure 404 {object} docs.ErrorResponse "Unknown provider"
+// @Failure 409 {object} docs.ErrorResponse "An unverified account with this email already exists"
+// @Failure 502 {object} docs.ErrorResponse "Provider unavailable"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /auth/{provider}/callback [get]
+func OAuthCallback(c *gin.Context) {
+	provider, ok := utils.GetOAuthProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	// Cookie state hanya berlaku untuk satu kali callback
+	cookie, _ := c.Cookie(oauthStateCookie)
+	setOAuthStateCookie(c, provider.Name(), "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login was denied at the provider: " + providerError})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	stored, err := utils.ConsumeOAuthState(config.DB, provider.Name(), state)
+	if err != nil {
+		if errors.Is(err, utils.ErrOAuthStateInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login state"})
+		return
+	}
+
+	external, err := provider.Exchange(c.Request.Context(), code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		log.Printf("Login lewat %s gagal: %v", provider.Name(), err)
+		if errors.Is(err, utils.ErrOAuthProviderUnavailable) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider unavailable"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login with provider failed"})
+		return
+	}
+
+	user, created, err := utils.ResolveIdentity(config.DB, external)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrIdentityEmailUnverified):
+			c.JSON(http.StatusForbidden, gin.H{"error": "The provider did not return a verified email address"})
+		case errors.Is(err, utils.ErrIdentityLinkRequiresLogin):
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists. Log in with your password and verify your email to link it"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link account"})
+		}
+		return
+	}
+	if created {
+		invalidateUser(user.ID)
+	}
+
+	if config.EmailVerificationPolicy() == "login" && user.EmailVerifiedAt == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
+		return
+	}
+
+	completeLogin(c, user)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final/config"
+	"final/models"
+	"final/utils"
+	"final/utils/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// oauthRouter registers a provider backed by an in-process fake issuer and returns
+// a function that runs the whole browser flow: login, provider approval and callback
+func oauthRouter(t *testing.T) (*oidctest.Issuer, *gin.Engine, func() *httptest.ResponseRecorder) {
+	issuer := oidctest.NewIssuer("client-id", "client-secret")
+	t.Cleanup(issuer.Close)
+
+	utils.SetOAuthProvider(utils.NewOIDCProvider(config.OAuthProviderConfig{
+		Name:         "fake",
+		Issuer:       issuer.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://api.test/auth/fake/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}, issuer.Client()))
+	t.Cleanup(func() { utils.RemoveOAuthProvider("fake") })
+
+	r := SetupTestRouter()
+	r.GET("/auth/:provider/login", OAuthLogin)
+	r.GET("/auth/:provider/callback", OAuthCallback)
+
+	signIn := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/auth/fake/login", nil)
+		login := httptest.NewRecorder()
+		r.ServeHTTP(login, req)
+		assert.Equal(t, http.StatusFound, login.Code)
+
+		// The "browser" visits the provider, which approves and redirects back
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		approved, err := client.Get(login.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to reach fake issuer: %v", err)
+		}
+		approved.Body.Close()
+		callback, _ := url.Parse(approved.Header.Get("Location"))
+
+		req, _ = http.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+		for _, cookie := range login.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	return issuer, r, signIn
+}
+
+func TestOAuthLoginCreatesUser(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		issuer, _, signIn := oauthRouter(t)
+		issuer.SetUser(oidctest.User{Subject: "sub-1", Email: "new@example.com", EmailVerified: true, PreferredUsername: "newbie"})
+
+		resp := signIn()
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "access_token")
+
+		var user models.User
+		assert.Nil(t, testDB.Where("email = ?", "new@example.com").First(&user).Error)
+		assert.Equal(t, "newbie", user.Username)
+		assert.NotNil(t, user.EmailVerifiedAt)
+
+		// Second login uses the linked identity, no new user
+		assert.Equal(t, http.StatusOK, signIn().Code)
+		var count int64
+		testDB.Model(&models.Identity{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestOAuthLoginLinksVerifiedEmail(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		issuer, _, signIn := oauthRouter(t)
+		user := CreateTestUser(t)
+		issuer.SetUser(oidctest.User{Subject: "sub-2", Email: user.Email, EmailVerified: true})
+
+		// Local account exists but its email was never verified
+		assert.Equal(t, http.StatusConflict, signIn().Code)
+
+		now := time.Now()
+		testDB.Model(&user).Update("email_verified_at", &now)
+		resp := signIn()
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var identity models.Identity
+		assert.Nil(t, testDB.Where("provider = ? AND subject = ?", "fake", "sub-2").First(&identity).Error)
+		assert.Equal(t, user.ID, identity.UserID)
+
+		// Unverified provider email is never used for linking or sign up
+		issuer.SetUser(oidctest.User{Subject: "sub-3", Email: "other@example.com", EmailVerified: false})
+		assert.Equal(t, http.StatusForbidden, signIn().Code)
+	})
+}
+
+func TestOAuthCallbackRejectsBadState(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		_, r, _ := oauthRouter(t)
+
+		req, _ := http.NewRequest(http.MethodGet, "/auth/fake/login", nil)
+		login := httptest.NewRecorder()
+		r.ServeHTTP(login, req)
+		location, _ := url.Parse(login.Header().Get("Location"))
+		state := location.Query().Get("state")
+
+		// State without the cookie from the same browser (login CSRF)
+		req, _ = http.NewRequest(http.MethodGet, "/auth/fake/callback?code=x&state="+url.QueryEscape(state), nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		// Unknown state with a matching cookie
+		req, _ = http.NewRequest(http.MethodGet, "/auth/fake/callback?code=x&state=forged", nil)
+		req.AddCookie(&http.Cookie{Name: "oauth_state", Value: "forged"})
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		req, _ = http.NewRequest(http.MethodGet, "/auth/unknown/login", nil)
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		var body map[string]interface{}
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	})
+}
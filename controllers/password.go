@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"final/config"
+	"final/mailer"
+	"final/models"
+	"final/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errResetTokenInvalid token reset tidak ditemukan, sudah dipakai atau kedaluwarsa
+var errResetTokenInvalid = errors.New("reset token invalid")
+
+// sendPasswordReset membuat token reset baru (token lama tidak berlaku lagi) lalu mengirim linknya ke user
+func sendPasswordReset(user models.User) error {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Token lama yang belum dipakai tidak berlaku lagi
+		now := time.Now()
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", &now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(time.Minute * time.Duration(config.PasswordResetExpiry())),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.AppBaseURL(), url.QueryEscape(token))
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: fmt.Sprintf("Halo %s,\n\nGunakan link berikut untuk mengatur ulang password Anda:\n%s\n\nLink ini berlaku selama %d menit dan hanya bisa digunakan sekali. Abaikan email ini jika Anda tidak memintanya.",
+			user.Username, link, config.PasswordResetExpiry()),
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Send a single-use password reset link to the given email. Always responds with success so registered emails cannot be discovered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body docs.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} map[string]string "Reset link sent if the email is registered"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
+// @Router /password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Respons selalu sama agar email yang terdaftar tidak bisa ditebak,
+	// kegagalan membuat token atau mengirim email hanya dicatat di log
+	response := gin.H{"message": "If the email is registered, a password reset link has been sent"}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if err := sendPasswordReset(user); err != nil {
+		log.Printf("Gagal mengirim email reset password ke user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a reset token. All existing sessions of the user are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body docs.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string "Password reset successfully"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - invalid or expired token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	var reset models.PasswordResetToken
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Tandai token terpakai secara atomik agar hanya satu request yang berhasil
+		now := time.Now()
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(input.Token), now).
+			Update("used_at", &now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		if err := tx.Where("token_hash = ?", utils.HashToken(input.Token)).First(&reset).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", reset.UserID).Update("password", hashedPassword).Error
+	})
+	if err != nil {
+		if errors.Is(err, errResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	invalidateUser(reset.UserID)
+
+	// Semua sesi yang masih aktif harus login ulang dengan password baru,
+	// access token yang sudah terbit ikut ditolak lewat token version
+	if err := utils.BumpTokenVersion(config.DB, reset.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but failed to revoke existing sessions"})
+		return
+	}
+	if err := utils.RevokeUserTokenFamilies(config.DB, reset.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but failed to revoke existing sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
+}
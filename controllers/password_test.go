@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"final/mailer"
+	"final/models"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// failingMailer fails every send, like an unreachable SMTP server
+type failingMailer struct{}
+
+func (failingMailer) Send(msg mailer.Message) error {
+	return errors.New("smtp unavailable")
+}
+
+// postJSON sends a JSON POST request to the router
+func postJSON(r http.Handler, path string, payload interface{}) *httptest.ResponseRecorder {
+	jsonValue, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		sink := &recordingMailer{}
+		previous := mailer.Default()
+		mailer.SetDefault(sink)
+		defer mailer.SetDefault(previous)
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		r.POST("/refresh", RefreshToken)
+		r.POST("/password/forgot", ForgotPassword)
+		r.POST("/password/reset", ResetPassword)
+
+		oldTokens := loginTestUser(t, r, testUser.Username)
+
+		// Unknown emails get the same response and no mail
+		resp := postJSON(r, "/password/forgot", map[string]string{"email": "nobody@example.com"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Len(t, sink.messages, 0)
+
+		resp = postJSON(r, "/password/forgot", map[string]string{"email": testUser.Email})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Len(t, sink.messages, 1)
+		assert.Equal(t, testUser.Email, sink.messages[0].To)
+
+		token := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`).FindStringSubmatch(sink.messages[0].Body)[1]
+
+		// Only the hash of the token is stored
+		var stored models.PasswordResetToken
+		testDB.Where("user_id = ?", testUser.ID).First(&stored)
+		assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+
+		resp = postJSON(r, "/password/reset", map[string]string{"token": token, "password": "brandnew123"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var updated models.User
+		testDB.First(&updated, testUser.ID)
+		assert.True(t, utils.CheckPasswordHash("brandnew123", updated.Password))
+
+		// The token is single-use
+		resp = postJSON(r, "/password/reset", map[string]string{"token": token, "password": "another123"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		// Sessions issued before the reset no longer work
+		resp = refreshWith(r, oldTokens["refresh_token"].(string))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestForgotPasswordDoesNotRevealEmails(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		previous := mailer.Default()
+		mailer.SetDefault(failingMailer{})
+		defer mailer.SetDefault(previous)
+
+		r := SetupTestRouter()
+		r.POST("/password/forgot", ForgotPassword)
+
+		unknown := postJSON(r, "/password/forgot", map[string]string{"email": "nobody@example.com"})
+		registered := postJSON(r, "/password/forgot", map[string]string{"email": testUser.Email})
+
+		// A failed send looks exactly like an unknown email
+		assert.Equal(t, http.StatusOK, unknown.Code)
+		assert.Equal(t, unknown.Code, registered.Code)
+		assert.Equal(t, unknown.Body.String(), registered.Body.String())
+	})
+}
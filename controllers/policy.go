@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser mengambil user yang sudah dimuat oleh middleware auth
+// Jika gagal, response 401 sudah dikirim dan ok bernilai false
+func currentUser(c *gin.Context) (models.User, bool) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  http.StatusUnauthorized,
+			"message": "User tidak terautentikasi",
+		})
+		return models.User{}, false
+	}
+	return user.User, true
+}
+
+// currentActor mengambil user yang sedang login sebagai Actor untuk pengecekan policy
+func currentActor(c *gin.Context) (utils.Actor, bool) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  http.StatusUnauthorized,
+			"message": "User tidak terautentikasi",
+		})
+		return utils.Actor{}, false
+	}
+	return user.Actor(), true
+}
+
+// authorize menjalankan policy untuk resource milik ownerID
+// Jika ditolak, response 403 dikirim dan hasilnya false
+func authorize(c *gin.Context, policy utils.Policy, actor utils.Actor, ownerID uint) bool {
+	if policy(actor, ownerID) {
+		return true
+	}
+
+	forbidden(c)
+	return false
+}
+
+// forbidden mengirim response 403 yang seragam untuk semua penolakan akses
+func forbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"status":  http.StatusForbidden,
+		"message": "Anda tidak memiliki akses ke resource ini",
+	})
+}
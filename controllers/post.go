@@ -5,6 +5,7 @@ import (
 	"errors"
 	"final/config"
 	"final/models"
+	"final/utils"
 	"log"
 	"net/http"
 	"os"
@@ -24,30 +25,18 @@ import (
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param post body models.Post true "Post data"
 // @Success 201 {object} models.Post "Post created successfully"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 429 {object} docs.QuotaExceededResponse "Daily quota exceeded"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /posts [post]
 func CreatePost(c *gin.Context) {
-	// Ambil username dari context yang sudah diset oleh middleware auth
-	username, exists := c.Get("username")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  http.StatusUnauthorized,
-			"message": "User tidak terautentikasi",
-		})
-		return
-	}
-
-	// Ambil data user dari database berdasarkan username
-	var user models.User
-	if err := config.DB.Where("username = ?", username).First(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Gagal mendapatkan data user",
-		})
+	// Ambil user yang sudah dimuat oleh middleware auth
+	user, ok := currentUser(c)
+	if !ok {
 		return
 	}
 
@@ -78,6 +67,8 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	invalidatePost(post)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  http.StatusCreated,
 		"message": "Post berhasil dibuat",
@@ -111,10 +102,12 @@ func InitCloudinary() {
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param file formData file true "Image file to upload (max 10MB)"
 // @Success 200 {object} map[string]interface{} "File uploaded successfully with URL and metadata"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - invalid file"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 429 {object} docs.QuotaExceededResponse "Daily quota exceeded"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /upload [post]
 func UploadToCloudinary(c *gin.Context) {
@@ -209,6 +202,7 @@ func UploadToCloudinary(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
 // @Success 200 {object} map[string]interface{} "List of posts with pagination metadata"
@@ -240,6 +234,9 @@ func GetPosts(c *gin.Context) {
 	
 	// Hitung total halaman
 	totalPages := (int(total) + limit - 1) / limit
+
+	tagCache(c, utils.TagPostsList)
+	tagPosts(c, posts...)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":  http.StatusOK,
@@ -261,6 +258,7 @@ func GetPosts(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path int true "Post ID"
 // @Success 200 {object} models.Post "Post details"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
@@ -289,7 +287,8 @@ func GetPost(c *gin.Context) {
 		})
 		return
 	}
-	
+
+	tagPosts(c, post)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  http.StatusOK,
 		"message": "Berhasil mengambil data post",
@@ -304,11 +303,13 @@ func GetPost(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path int true "Post ID"
 // @Param post body object true "Updated post data" schema(title=string,body=string)
 // @Success 200 {object} models.Post "Post updated successfully"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - not the owner of the post"
 // @Failure 404 {object} docs.ErrorResponse "Post not found"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /posts/{id} [put]
@@ -324,6 +325,15 @@ func UpdatePost(c *gin.Context) {
 		})
 		return
 	}
+
+	// Hanya pemilik post atau moderator yang boleh mengubah
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, utils.CanEditPost, actor, post.UserID) {
+		return
+	}
 	
 	// Validasi input JSON
 	var input struct {
@@ -373,6 +383,8 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 	
+	invalidatePost(post)
+
 	// Ambil post yang sudah diupdate
 	config.DB.First(&post, id)
 	
@@ -390,9 +402,11 @@ func UpdatePost(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]string "Post deleted successfully"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - not the owner of the post"
 // @Failure 404 {object} docs.ErrorResponse "Post not found"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /posts/{id} [delete]
@@ -408,6 +422,15 @@ func DeletePost(c *gin.Context) {
 		})
 		return
 	}
+
+	// Hanya pemilik post atau moderator yang boleh menghapus
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, utils.CanEditPost, actor, post.UserID) {
+		return
+	}
 	
 	// Hapus post
 	result := config.DB.Delete(&post)
@@ -419,7 +442,8 @@ func DeletePost(c *gin.Context) {
 		})
 		return
 	}
-	
+
+	invalidatePost(post)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  http.StatusOK,
 		"message": "Post berhasil dihapus",
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestPost creates a post owned by the given user
+func createTestPost(t *testing.T, owner models.User) models.Post {
+	post := models.Post{Title: "Judul", Body: "Isi", UserID: owner.ID}
+	if err := testDB.Create(&post).Error; err != nil {
+		t.Fatalf("Failed to create test post: %v", err)
+	}
+	return post
+}
+
+// updatePostAs sends PUT /posts/:id authenticated as the given user
+func updatePostAs(user models.User, postID uint) *httptest.ResponseRecorder {
+	r := SetupTestRouter()
+	r.PUT("/posts/:id", AuthenticateAs(user), UpdatePost)
+
+	jsonValue, _ := json.Marshal(map[string]string{"title": "Baru", "body": "Isi baru"})
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/posts/%d", postID), bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+// deletePostAs sends DELETE /posts/:id authenticated as the given user
+func deletePostAs(user models.User, postID uint) *httptest.ResponseRecorder {
+	r := SetupTestRouter()
+	r.DELETE("/posts/:id", AuthenticateAs(user), DeletePost)
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/posts/%d", postID), nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestUpdatePostOwnership(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		owner := CreateTestUser(t)
+		other := CreateTestUserWithRole(t, "otheruser", "other@example.com", "user")
+		admin := CreateTestUserWithRole(t, "adminuser", "admin@example.com", "admin")
+		post := createTestPost(t, owner)
+
+		// Another user cannot edit the post
+		resp := updatePostAs(other, post.ID)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		var unchanged models.Post
+		testDB.First(&unchanged, post.ID)
+		assert.Equal(t, "Judul", unchanged.Title)
+
+		// The owner and an admin can
+		assert.Equal(t, http.StatusOK, updatePostAs(owner, post.ID).Code)
+		assert.Equal(t, http.StatusOK, updatePostAs(admin, post.ID).Code)
+	})
+}
+
+func TestDeletePostOwnership(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		owner := CreateTestUser(t)
+		other := CreateTestUserWithRole(t, "otheruser", "other@example.com", "user")
+		post := createTestPost(t, owner)
+
+		// Another user cannot delete the post
+		resp := deletePostAs(other, post.ID)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Nil(t, testDB.First(&models.Post{}, post.ID).Error)
+
+		// The owner can
+		assert.Equal(t, http.StatusOK, deletePostAs(owner, post.ID).Code)
+	})
+}
+
+func TestPostCacheInvalidatedOnUpdate(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		owner := CreateTestUser(t)
+		post := createTestPost(t, owner)
+
+		cache := middleware.NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+		utils.SetCacheInvalidator(cache)
+		defer utils.SetCacheInvalidator(nil)
+
+		r := SetupTestRouter()
+		r.GET("/posts/:id", AuthenticateAs(owner), cache.Middleware(), GetPost)
+
+		get := func() *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/posts/%d", post.ID), nil)
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp
+		}
+
+		get()
+		assert.Equal(t, "HIT", get().Header().Get("X-Cache"))
+
+		// The owner's own edit is visible immediately
+		assert.Equal(t, http.StatusOK, updatePostAs(owner, post.ID).Code)
+		resp := get()
+		assert.NotEqual(t, "HIT", resp.Header().Get("X-Cache"))
+		assert.Contains(t, resp.Body.String(), "Isi baru")
+	})
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"final/config"
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentSessionID mengambil ID sesi (token family) dari claim sid access token
+// Kosong untuk API key dan token lama yang belum membawa sid
+func currentSessionID(c *gin.Context) string {
+	if user, ok := middleware.CurrentUser(c); ok {
+		return user.SessionID
+	}
+	return ""
+}
+
+// sessionResponse menampilkan sesi dan menandai sesi yang sedang dipakai
+func sessionResponse(family models.TokenFamily, currentID string) gin.H {
+	return gin.H{
+		"id":                family.ID,
+		"user_agent":        family.UserAgent,
+		"ip":                family.IP,
+		"created_at":        family.CreatedAt,
+		"last_refreshed_at": family.LastRefreshedAt,
+		"current":           family.ID == currentID,
+	}
+}
+
+// ListSessions godoc
+// @Summary List my sessions
+// @Description List the active logins (devices) of the logged in user. The session of the current token is marked with current=true.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} docs.SessionResponse "Active sessions"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage sessions"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/sessions [get]
+func ListSessions(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	families, err := utils.ActiveTokenFamilies(config.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+
+	currentID := currentSessionID(c)
+	sessions := make([]gin.H, 0, len(families))
+	for _, family := range families {
+		sessions = append(sessions, sessionResponse(family, currentID))
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary End a session
+// @Description Log out one of my sessions. Its access and refresh tokens stop working immediately. Ending the current session is the same as logging out.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string "Session ended"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage sessions"
+// @Failure 404 {object} docs.ErrorResponse "Session not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Sesi milik user lain atau yang sudah berakhir dianggap tidak ada
+	var family models.TokenFamily
+	if err := config.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("id"), user.ID).First(&family).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := utils.RevokeTokenFamily(config.DB, family.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session ended"})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Log out everywhere else
+// @Description End all my sessions except the one making this request
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} docs.RevokeSessionsResponse "Number of sessions ended"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - API keys and impersonation tokens cannot manage sessions"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/sessions [delete]
+func RevokeOtherSessions(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := utils.RevokeOtherTokenFamilies(config.DB, user.ID, currentSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out from all other sessions",
+		"revoked": revoked,
+	})
+}
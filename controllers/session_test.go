@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionRequest sends an authenticated request with the given access token
+func sessionRequest(r http.Handler, method, path, accessToken string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestSessions(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		testUser := CreateTestUser(t)
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		r.POST("/refresh", RefreshToken)
+		auth := r.Group("/", middleware.AuthMiddleware())
+		auth.GET("/me/sessions", ListSessions)
+		auth.DELETE("/me/sessions", RevokeOtherSessions)
+		auth.DELETE("/me/sessions/:id", RevokeSession)
+
+		// Three logins from different devices
+		laptop := loginTestUser(t, r, testUser.Username)
+		phone := loginTestUser(t, r, testUser.Username)
+		tablet := loginTestUser(t, r, testUser.Username)
+		access := laptop["access_token"].(string)
+
+		resp := sessionRequest(r, http.MethodGet, "/me/sessions", access)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		var sessions []gin.H
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &sessions))
+		assert.Len(t, sessions, 3)
+
+		current := 0
+		var other string
+		for _, session := range sessions {
+			if session["current"] == true {
+				current++
+			} else if other == "" {
+				other = session["id"].(string)
+			}
+		}
+		assert.Equal(t, 1, current)
+
+		// Ending one session kills its refresh token
+		resp = sessionRequest(r, http.MethodDelete, "/me/sessions/"+other, access)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = sessionRequest(r, http.MethodDelete, "/me/sessions/"+other, access)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		// Log out everywhere else leaves only the current session
+		resp = sessionRequest(r, http.MethodDelete, "/me/sessions", access)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"revoked":1`)
+
+		assert.Equal(t, http.StatusUnauthorized, refreshWith(r, phone["refresh_token"].(string)).Code)
+		assert.Equal(t, http.StatusUnauthorized, refreshWith(r, tablet["refresh_token"].(string)).Code)
+		assert.Equal(t, http.StatusOK, refreshWith(r, laptop["refresh_token"].(string)).Code)
+
+		resp = sessionRequest(r, http.MethodGet, "/me/sessions", access)
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &sessions))
+		assert.Len(t, sessions, 1)
+		assert.NotNil(t, sessions[0]["last_refreshed_at"])
+	})
+}
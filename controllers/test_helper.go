@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"final/config"
+	"final/middleware"
 	"final/models"
 	"final/utils"
 	"log"
@@ -63,6 +64,11 @@ func ClearTestDB() {
 
 // CreateTestUser creates a test user in the database
 func CreateTestUser(t *testing.T) models.User {
+	return CreateTestUserWithRole(t, "testuser", "testuser@example.com", "user")
+}
+
+// CreateTestUserWithRole creates a test user with the given username, email and role
+func CreateTestUserWithRole(t *testing.T, username, email, role string) models.User {
 	// Hash the password
 	hashedPassword, err := utils.HashPassword("password123")
 	if err != nil {
@@ -71,9 +77,10 @@ func CreateTestUser(t *testing.T) models.User {
 
 	// Create a test user
 	user := models.User{
-		Username: "testuser",
-		Email:    "testuser@example.com",
+		Username: username,
+		Email:    email,
 		Password: hashedPassword,
+		Role:     role,
 	}
 
 	// Save to database
@@ -85,6 +92,14 @@ func CreateTestUser(t *testing.T) models.User {
 	return user
 }
 
+// AuthenticateAs returns a middleware that sets the context the same way AuthMiddleware does
+func AuthenticateAs(user models.User) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		middleware.SetCurrentUser(c, &utils.CurrentUser{User: user})
+		c.Next()
+	}
+}
+
 // SetupTestRouter returns a configured Gin router for testing
 func SetupTestRouter() *gin.Engine {
 	// Set Gin to test mode
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"final/config"
+	"final/utils"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyUsage godoc
+// @Summary Get my quota usage
+// @Description Get the rate limit tier and today's usage of daily quotas for the logged in user. Daily quotas reset at 00:00 UTC.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Success 200 {object} docs.UsageResponse "Quota usage"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /me/usage [get]
+func GetMyUsage(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	usage, err := utils.DailyUsage(config.DB, user.ID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load usage"})
+		return
+	}
+
+	role := c.GetString("role")
+	quota := utils.QuotaFor(role)
+
+	// Tampilkan semua operasi yang dibatasi ditambah operasi lain yang sudah dipakai hari ini
+	ops := make(map[utils.QuotaOperation]bool)
+	for op := range quota.Daily {
+		ops[op] = true
+	}
+	for op := range usage {
+		ops[op] = true
+	}
+
+	quotas := make([]gin.H, 0, len(ops))
+	for op := range ops {
+		entry := gin.H{"operation": op, "used": usage[op]}
+		if limit, limited := quota.Daily[op]; limited {
+			remaining := limit - usage[op]
+			if remaining < 0 {
+				remaining = 0
+			}
+			entry["limit"] = limit
+			entry["remaining"] = remaining
+		}
+		quotas = append(quotas, entry)
+	}
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i]["operation"].(utils.QuotaOperation) < quotas[j]["operation"].(utils.QuotaOperation)
+	})
+
+	// Cegah respons disimpan cache karena pemakaian berubah setiap request
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"tier": role,
+		"rate_limit": gin.H{
+			"requests_per_minute": quota.RequestsPerMinute,
+			"burst":               quota.Burst,
+		},
+		"date":     utils.QuotaDay(now).Format("2006-01-02"),
+		"reset_at": utils.QuotaResetAt(now),
+		"quotas":   quotas,
+	})
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/json"
+	"final/middleware"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyPostQuota(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		user := CreateTestUser(t)
+		limit := utils.QuotaFor(user.Role).Daily[utils.OpPostCreate]
+
+		r := SetupTestRouter()
+		r.POST("/posts", AuthenticateAs(user), middleware.RequireDailyQuota(utils.OpPostCreate), CreatePost)
+		r.GET("/me/usage", AuthenticateAs(user), GetMyUsage)
+
+		// A rejected request does not use up the quota
+		resp := postJSON(r, "/posts", map[string]string{"title": "missing body"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		for i := 0; i < limit; i++ {
+			resp = postJSON(r, "/posts", map[string]string{"title": "Post", "body": "Body"})
+			assert.Equal(t, http.StatusCreated, resp.Code)
+		}
+
+		resp = postJSON(r, "/posts", map[string]string{"title": "Post", "body": "Body"})
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+
+		var body struct {
+			Quota struct {
+				Operation string `json:"operation"`
+				Limit     int    `json:"limit"`
+				Used      int    `json:"used"`
+			} `json:"quota"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.Equal(t, string(utils.OpPostCreate), body.Quota.Operation)
+		assert.Equal(t, limit, body.Quota.Used)
+
+		req, _ := http.NewRequest(http.MethodGet, "/me/usage", nil)
+		resp = httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var usage struct {
+			Tier   string `json:"tier"`
+			Quotas []struct {
+				Operation string `json:"operation"`
+				Used      int    `json:"used"`
+				Remaining int    `json:"remaining"`
+			} `json:"quotas"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &usage)
+		assert.Equal(t, user.Role, usage.Tier)
+		for _, q := range usage.Quotas {
+			if q.Operation == string(utils.OpPostCreate) {
+				assert.Equal(t, limit, q.Used)
+				assert.Equal(t, 0, q.Remaining)
+			}
+		}
+	})
+}
@@ -3,6 +3,8 @@ package controllers
 import (
 	database "final/config"
 	"final/models"
+	"final/utils"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,10 +17,12 @@ import (
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param user body models.User true "User data"
 // @Success 201 {object} models.User "User created successfully"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - insufficient permission"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /users [post]
 func CreateUser(c *gin.Context) {
@@ -28,12 +32,21 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Password tidak pernah disimpan dalam bentuk asli
+	hashedPassword, err := utils.HashPassword(user.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	user.Password = hashedPassword
+
 	// Simpan user ke database
 	if err := database.DB.Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
+	invalidateUser(user.ID)
 	c.JSON(http.StatusCreated, user)
 }
 
@@ -44,6 +57,7 @@ func CreateUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Success 200 {array} models.User "List of users"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
@@ -54,6 +68,8 @@ func GetUsers(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
+	tagCache(c, utils.TagUsersList)
+	tagUsers(c, users...)
 	c.JSON(http.StatusOK, users)
 }
 
@@ -64,6 +80,7 @@ func GetUsers(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path int true "User ID"
 // @Success 200 {object} models.User "User details"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
@@ -78,21 +95,23 @@ func GetUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	tagUsers(c, user)
 	c.JSON(http.StatusOK, user)
 }
 
 // UpdateUser godoc
 // @Summary Update a user
-// @Description Update user details by user ID
+// @Description Update user details by user ID. Users may update themselves, admins may update anyone. Only the user themselves may change the password and only admins may change roles. Changing the email marks it as unverified and sends a new verification link.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "User ID"
-// @Param user body models.User true "Updated user data"
+// @Param user body docs.UpdateUserRequest true "Updated user data"
 // @Success 200 {object} models.User "User updated successfully"
 // @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - not allowed to modify this user"
 // @Failure 404 {object} docs.ErrorResponse "User not found"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /users/{id} [put]
@@ -106,25 +125,87 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// Validasi input JSON
-	var updatedUser models.User
-	if err := c.ShouldBindJSON(&updatedUser); err != nil {
+	// Hanya user itu sendiri atau admin yang boleh mengubah
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, utils.CanEditUser, actor, user.ID) {
+		return
+	}
+
+	// Validasi input JSON, semua field opsional
+	var input struct {
+		Username string `json:"username"`
+		Email    string `json:"email" binding:"omitempty,email"`
+		Password string `json:"password" binding:"omitempty,min=8"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	updates := map[string]interface{}{}
+	if input.Username != "" {
+		updates["username"] = input.Username
+	}
+	// Email baru harus diverifikasi ulang, status verifikasi email lama tidak ikut pindah
+	emailChanged := input.Email != "" && input.Email != user.Email
+	if emailChanged {
+		updates["email"] = input.Email
+		updates["email_verified_at"] = nil
+	}
+
+	// Password hanya boleh diganti oleh pemilik akun
+	if input.Password != "" {
+		if !authorize(c, utils.CanChangePassword, actor, user.ID) {
+			return
+		}
+		hashedPassword, err := utils.HashPassword(input.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		updates["password"] = hashedPassword
+	}
+
+	// Role hanya boleh diganti oleh admin
+	if input.Role != "" && input.Role != user.Role {
+		if !actor.Can(utils.PermUsersManage) {
+			forbidden(c)
+			return
+		}
+		if !utils.IsValidRole(input.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+		updates["role"] = input.Role
+	}
+
 	// Update data user
-	if err := database.DB.Model(&user).Updates(updatedUser).Error; err != nil {
+	// Token tetap berlaku setelah username atau role berubah karena user dikenali dari ID
+	// dan role dibaca ulang di setiap request
+	if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
 
+	if emailChanged {
+		user.Email = input.Email
+		user.EmailVerifiedAt = nil
+		if err := sendVerificationEmail(user); err != nil {
+			log.Printf("Gagal mengirim email verifikasi ke user %d: %v", user.ID, err)
+		}
+	}
+
+	invalidateUser(user.ID)
 	c.JSON(http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary Delete a user
-// @Description Delete a user by user ID
+// @Description Delete a user by user ID. Users may delete themselves, admins may delete anyone.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -132,6 +213,7 @@ func UpdateUser(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]string "User deleted successfully"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - not allowed to delete this user"
 // @Failure 404 {object} docs.ErrorResponse "User not found"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
 // @Router /users/{id} [delete]
@@ -145,15 +227,59 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// Hanya user itu sendiri atau admin yang boleh menghapus
+	actor, ok := currentActor(c)
+	if !ok {
+		return
+	}
+	if !authorize(c, utils.CanEditUser, actor, user.ID) {
+		return
+	}
+
 	// Hapus user dari database
 	if err := database.DB.Delete(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
 
+	invalidateUser(user.ID)
+	utils.InvalidateCachedUserTokens(user.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 }
 
+// RevokeUserTokens godoc
+// @Summary Revoke all tokens of a user
+// @Description Invalidate every access and refresh token of the user at once, for example when an account is compromised. API keys are not affected.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string "Tokens revoked"
+// @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
+// @Failure 403 {object} docs.ErrorResponse "Forbidden - admin only"
+// @Failure 404 {object} docs.ErrorResponse "User not found"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/revoke-tokens [post]
+func RevokeUserTokens(c *gin.Context) {
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Token version membuat semua token lama ditolak, sesi ikut diakhiri agar tidak tampil di /me/sessions
+	if err := utils.BumpTokenVersion(database.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke tokens"})
+		return
+	}
+	if err := utils.RevokeUserTokenFamilies(database.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All tokens of the user have been revoked"})
+}
+
 // GetUsersWithPosts godoc
 // @Summary Get all users with their posts
 // @Description Get a list of all users including their posts
@@ -161,6 +287,7 @@ func DeleteUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security APIKeyAuth
 // @Success 200 {object} map[string]interface{} "List of users with their posts"
 // @Failure 401 {object} docs.ErrorResponse "Unauthorized - invalid token"
 // @Failure 500 {object} docs.ErrorResponse "Internal server error"
@@ -177,6 +304,9 @@ func GetUsersWithPosts(c *gin.Context) {
 		return
 	}
 
+	tagCache(c, utils.TagUsersList, utils.TagPostsList)
+	tagUsers(c, users...)
+
 	// Jika berhasil, kirim data dengan status code
 	c.JSON(http.StatusOK, gin.H{
 		"status":  http.StatusOK,
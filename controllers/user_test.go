@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"final/middleware"
+	"final/models"
+	"final/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// updateUserAs sends PUT /users/:id authenticated as the given user
+func updateUserAs(actor models.User, userID uint, payload map[string]string) *httptest.ResponseRecorder {
+	r := SetupTestRouter()
+	r.PUT("/users/:id", AuthenticateAs(actor), UpdateUser)
+
+	jsonValue, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", userID), bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestUpdateUserOwnership(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		target := CreateTestUser(t)
+		other := CreateTestUserWithRole(t, "otheruser", "other@example.com", "user")
+		admin := CreateTestUserWithRole(t, "adminuser", "admin@example.com", "admin")
+
+		// Another user cannot edit the profile or escalate their own role
+		resp := updateUserAs(other, target.ID, map[string]string{"email": "hijack@example.com"})
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		resp = updateUserAs(other, other.ID, map[string]string{"role": "admin"})
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		// The user themselves and an admin can edit the profile
+		resp = updateUserAs(target, target.ID, map[string]string{"email": "new@example.com"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+		resp = updateUserAs(admin, target.ID, map[string]string{"email": "admin-set@example.com"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
+
+func TestChangePasswordSelfOnly(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		target := CreateTestUser(t)
+		admin := CreateTestUserWithRole(t, "adminuser", "admin@example.com", "admin")
+
+		// Not even an admin may change another user's password
+		resp := updateUserAs(admin, target.ID, map[string]string{"password": "adminchosen123"})
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		// The user can, and the new password is stored hashed
+		resp = updateUserAs(target, target.ID, map[string]string{"password": "newpassword123"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var updated models.User
+		testDB.First(&updated, target.ID)
+		assert.True(t, utils.CheckPasswordHash("newpassword123", updated.Password))
+	})
+}
+
+func TestDeleteUserOwnership(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		target := CreateTestUser(t)
+		other := CreateTestUserWithRole(t, "otheruser", "other@example.com", "user")
+
+		r := SetupTestRouter()
+		r.DELETE("/users/:id", AuthenticateAs(other), DeleteUser)
+
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%d", target.ID), nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Nil(t, testDB.First(&models.User{}, target.ID).Error)
+	})
+}
+
+func TestTokensSurviveRenameAndRevokeByVersion(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		user := CreateTestUser(t)
+		admin := CreateTestUserWithRole(t, "admin", "admin@example.com", utils.RoleAdmin)
+
+		r := SetupTestRouter()
+		r.POST("/login", Login)
+		r.POST("/refresh", RefreshToken)
+		r.PUT("/users/:id", middleware.AuthMiddleware(), UpdateUser)
+		r.POST("/admin/users/:id/revoke-tokens", AuthenticateAs(admin), RevokeUserTokens)
+
+		tokens := loginTestUser(t, r, user.Username)
+		access := tokens["access_token"].(string)
+
+		rename := func(username string) *httptest.ResponseRecorder {
+			jsonValue, _ := json.Marshal(map[string]string{"username": username})
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", user.ID), bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+access)
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp
+		}
+
+		// The token keeps pointing at the same user after a rename
+		assert.Equal(t, http.StatusOK, rename("renamed").Code)
+		assert.Equal(t, http.StatusOK, rename("renamed-again").Code)
+
+		// A new user taking the old username does not inherit the token
+		CreateTestUserWithRole(t, user.Username, "other@example.com", utils.RoleUser)
+		assert.Equal(t, http.StatusOK, rename("renamed-third").Code)
+
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/admin/users/%d/revoke-tokens", user.ID), nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, rename("after-revoke").Code)
+		assert.Equal(t, http.StatusUnauthorized, refreshWith(r, tokens["refresh_token"].(string)).Code)
+	})
+}
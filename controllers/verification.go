@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"final/config"
+	"final/mailer"
+	"final/models"
+	"final/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendVerificationEmail mengirim link verifikasi email ke user
+func sendVerificationEmail(user models.User) error {
+	token, err := utils.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.AppBaseURL(), url.QueryEscape(token))
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email",
+		Body: fmt.Sprintf("Halo %s,\n\nKlik link berikut untuk memverifikasi email Anda:\n%s\n\nLink ini berlaku selama %d jam.",
+			user.Username, link, config.EmailVerificationExpiryTime()),
+	})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Mark the user's email as verified using the signed token from the verification link
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body docs.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]string "Email verified successfully"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - invalid or expired token"
+// @Failure 500 {object} docs.ErrorResponse "Internal server error"
+// @Router /verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, email, err := utils.ParseEmailVerificationToken(input.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	// Token hanya berlaku untuk email yang sama dengan saat link dibuat
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil || user.Email != email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&user).Update("email_verified_at", &now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+		invalidateUser(user.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Send a new verification link if the email is registered and not yet verified. Always responds with success so registered emails cannot be discovered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body docs.ResendVerificationRequest true "Account email"
+// @Success 200 {object} map[string]string "Verification link sent if applicable"
+// @Failure 400 {object} docs.ErrorResponse "Bad request - validation error"
+// @Router /verify-email/resend [post]
+func ResendVerificationEmail(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "If the email is registered and not yet verified, a verification link has been sent"}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil || user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Kegagalan mengirim hanya dicatat di log agar respons tetap sama dengan email yang tidak terdaftar
+	if err := sendVerificationEmail(user); err != nil {
+		log.Printf("Gagal mengirim email verifikasi ke user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
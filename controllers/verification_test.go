@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"final/mailer"
+	"final/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerificationFlow(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		t.Setenv("EMAIL_VERIFICATION_POLICY", "login")
+
+		sink := &recordingMailer{}
+		previous := mailer.Default()
+		mailer.SetDefault(sink)
+		defer mailer.SetDefault(previous)
+
+		r := SetupTestRouter()
+		r.POST("/register", Register)
+		r.POST("/login", Login)
+		r.POST("/verify-email", VerifyEmail)
+
+		resp := postJSON(r, "/register", map[string]string{
+			"username": "newuser",
+			"email":    "newuser@example.com",
+			"password": "password123",
+		})
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Len(t, sink.messages, 1)
+
+		// Login is blocked until the email is verified
+		resp = postJSON(r, "/login", map[string]string{"username": "newuser", "password": "password123"})
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		// A tampered token is rejected
+		resp = postJSON(r, "/verify-email", map[string]string{"token": "not-a-token"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		token := regexp.MustCompile(`token=([A-Za-z0-9_.-]+)`).FindStringSubmatch(sink.messages[0].Body)[1]
+		resp = postJSON(r, "/verify-email", map[string]string{"token": token})
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var user models.User
+		testDB.Where("username = ?", "newuser").First(&user)
+		assert.NotNil(t, user.EmailVerifiedAt)
+
+		resp = postJSON(r, "/login", map[string]string{"username": "newuser", "password": "password123"})
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
+
+func TestEmailChangeClearsVerification(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		sink := &recordingMailer{}
+		previous := mailer.Default()
+		mailer.SetDefault(sink)
+		defer mailer.SetDefault(previous)
+
+		user := CreateTestUser(t)
+		verifiedAt := time.Now()
+		testDB.Model(&user).Update("email_verified_at", &verifiedAt)
+
+		r := SetupTestRouter()
+		r.PUT("/users/:id", AuthenticateAs(user), UpdateUser)
+
+		update := func(payload map[string]string) int {
+			jsonValue, _ := json.Marshal(payload)
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", user.ID), bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp.Code
+		}
+
+		// Sending the current email again keeps the verification
+		assert.Equal(t, http.StatusOK, update(map[string]string{"email": user.Email}))
+		var reloaded models.User
+		testDB.First(&reloaded, user.ID)
+		assert.NotNil(t, reloaded.EmailVerifiedAt)
+		assert.Len(t, sink.messages, 0)
+
+		// A new email is unverified until the link sent to it is used
+		assert.Equal(t, http.StatusOK, update(map[string]string{"email": "changed@example.com"}))
+		reloaded = models.User{}
+		testDB.First(&reloaded, user.ID)
+		assert.Equal(t, "changed@example.com", reloaded.Email)
+		assert.Nil(t, reloaded.EmailVerifiedAt)
+		if assert.Len(t, sink.messages, 1) {
+			assert.Equal(t, "changed@example.com", sink.messages[0].To)
+		}
+	})
+}
+
+func TestResendVerificationDoesNotRevealEmails(t *testing.T) {
+	RunWithTransaction(t, func(t *testing.T) {
+		user := CreateTestUser(t)
+
+		previous := mailer.Default()
+		mailer.SetDefault(failingMailer{})
+		defer mailer.SetDefault(previous)
+
+		r := SetupTestRouter()
+		r.POST("/verify-email/resend", ResendVerificationEmail)
+
+		unknown := postJSON(r, "/verify-email/resend", map[string]string{"email": "nobody@example.com"})
+		registered := postJSON(r, "/verify-email/resend", map[string]string{"email": user.Email})
+
+		// A failed send looks exactly like an unknown email
+		assert.Equal(t, http.StatusOK, unknown.Code)
+		assert.Equal(t, unknown.Code, registered.Code)
+		assert.Equal(t, unknown.Body.String(), registered.Body.String())
+	})
+}
@@ -24,66 +24,35 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/login": {
-            "post": {
-                "description": "Authenticate user and return JWT token",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys used to verify tokens issued by this API, including keys that were rotated out but may still sign live tokens",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "Login user",
-                "parameters": [
-                    {
-                        "description": "User login credentials",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/docs.LoginRequest"
-                        }
-                    }
-                ],
+                "summary": "JSON Web Key Set",
                 "responses": {
                     "200": {
-                        "description": "Login successful",
-                        "schema": {
-                            "$ref": "#/definitions/docs.TokenResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request - validation error",
-                        "schema": {
-                            "$ref": "#/definitions/docs.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized - invalid credentials",
-                        "schema": {
-                            "$ref": "#/definitions/docs.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
+                        "description": "JWK set",
                         "schema": {
-                            "$ref": "#/definitions/docs.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/logout": {
+        "/2fa/activate": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Invalidate user's refresh token",
+                "description": "Confirm enrollment with a code from the authenticator app to turn on 2FA",
                 "consumes": [
                     "application/json"
                 ],
@@ -91,12 +60,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "2fa"
+                ],
+                "summary": "Activate TOTP",
+                "parameters": [
+                    {
+                        "description": "Current TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.TOTPCodeRequest"
+                        }
+                    }
                 ],
-                "summary": "Logout user",
                 "responses": {
                     "200": {
-                        "description": "Logout successful",
+                        "description": "2FA enabled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -104,6 +84,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad request - invalid code or no pending enrollment",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized - invalid token",
                         "schema": {
@@ -119,14 +105,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts": {
-            "get": {
+        "/2fa/disable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of all posts with pagination",
+                "description": "Turn off 2FA. Requires the account password and a TOTP or recovery code.",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,35 +120,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "2fa"
                 ],
-                "summary": "Get all posts",
+                "summary": "Disable TOTP",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Number of items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Password and TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.TOTPDisableRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of posts with pagination metadata",
+                        "description": "2FA disabled",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - 2FA not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized - invalid token",
+                        "description": "Unauthorized - invalid password or code",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -174,14 +163,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/2fa/enroll": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new post with provided data",
+                "description": "Generate a new TOTP secret and recovery codes. 2FA is not active until confirmed through /2fa/activate.",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,29 +180,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
-                ],
-                "summary": "Create a new post",
-                "parameters": [
-                    {
-                        "description": "Post data",
-                        "name": "post",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Post"
-                        }
-                    }
+                    "2fa"
                 ],
+                "summary": "Start TOTP enrollment",
                 "responses": {
-                    "201": {
-                        "description": "Post created successfully",
+                    "200": {
+                        "description": "otpauth URI, secret and recovery codes",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/docs.TOTPEnrollResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Bad request - 2FA already enabled",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -231,38 +211,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/posts/{id}": {
+        "/admin/impersonations": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get post details by post ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List impersonation starts and ends and every request made with an impersonation token, newest first. Filter by admin_id, user_id or token_id.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
                 ],
-                "summary": "Get a post by ID",
+                "summary": "List impersonation audit log",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Real admin ID",
+                        "name": "admin_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Impersonated user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Impersonation token ID (jti)",
+                        "name": "token_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100, max 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Post details",
+                        "description": "Audit entries",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/docs.ImpersonationAuditResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
                     "401": {
@@ -271,8 +274,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Post not found",
+                    "403": {
+                        "description": "Forbidden - admin only",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -284,14 +287,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/users/{id}/impersonate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update post details by post ID",
+                "description": "Issue a short-lived access token that acts as the user, for reproducing issues. The token carries an ` + "`" + `act` + "`" + ` claim with the admin, has no refresh token and cannot be used for account management (password, 2FA, API keys, sessions, deletion). Every request made with it is recorded in the impersonation audit log.",
                 "consumes": [
                     "application/json"
                 ],
@@ -299,36 +304,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
                 ],
-                "summary": "Update a post",
+                "summary": "Impersonate a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated post data",
-                        "name": "post",
+                        "description": "Reason for the impersonation",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/docs.ImpersonateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Post updated successfully",
+                    "201": {
+                        "description": "Impersonation token issued",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/docs.ImpersonationResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Bad request - reason missing or impersonating yourself",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -339,8 +344,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not allowed to impersonate this user",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Post not found",
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -352,28 +363,27 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{id}/revoke-tokens": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a post by post ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Invalidate every access and refresh token of the user at once, for example when an account is compromised. API keys are not affected.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "admin"
                 ],
-                "summary": "Delete a post",
+                "summary": "Revoke all tokens of a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -381,7 +391,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Post deleted successfully",
+                        "description": "Tokens revoked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -395,8 +405,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - admin only",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Post not found",
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -410,9 +426,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/refresh": {
+        "/admin/users/{id}/unlock": {
             "post": {
-                "description": "Generate new access token using refresh token",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear the failed login counter of a user so they can log in again immediately. Optionally also clear the counter of an IP address.",
                 "consumes": [
                     "application/json"
                 ],
@@ -420,36 +441,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Refresh access token",
+                "summary": "Unlock a user account",
                 "parameters": [
                     {
-                        "example": "\"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...\"",
-                        "description": "Refresh token",
-                        "name": "refresh_token",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional IP address to unlock",
+                        "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/docs.UnlockUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "New tokens generated successfully",
+                        "description": "Account unlocked",
                         "schema": {
-                            "$ref": "#/definitions/docs.TokenResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad request - invalid refresh token",
+                    "401": {
+                        "description": "Unauthorized - invalid token",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized - expired or invalid token",
+                    "403": {
+                        "description": "Forbidden - admin only",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -463,39 +498,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Register a new user with username, email and password",
-                "consumes": [
-                    "application/json"
-                ],
+        "/auth/providers": {
+            "get": {
+                "description": "Names of the configured external login providers, for use in /auth/{provider}/login",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "Register a new user",
-                "parameters": [
-                    {
-                        "description": "User registration data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                "summary": "List login providers",
+                "responses": {
+                    "200": {
+                        "description": "Configured providers",
                         "schema": {
-                            "$ref": "#/definitions/docs.RegisterRequest"
+                            "$ref": "#/definitions/docs.OAuthProvidersResponse"
                         }
                     }
+                }
+            }
+        },
+        "/auth/{provider}/callback": {
+            "get": {
+                "description": "Exchanges the authorization code for a verified identity and logs in the linked user. On first login the account is linked by verified email, or a new user is created. Returns tokens like /login, or an mfa_token when 2FA is enabled.",
+                "produces": [
+                    "application/json"
                 ],
-                "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish login with an external provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "google",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State from /auth/{provider}/login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login successful",
                         "schema": {
-                            "$ref": "#/definitions/docs.UserResponse"
+                            "$ref": "#/definitions/docs.TokenResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Identity verified, second factor required",
+                        "schema": {
+                            "$ref": "#/definitions/docs.MFARequiredResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error or username/email already exists",
+                        "description": "Invalid, expired or reused state, or login denied at the provider",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Provider rejected the code or returned an invalid ID token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Provider did not verify the email address",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An unverified account with this email already exists",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -505,47 +600,85 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/upload": {
+        "/auth/{provider}/login": {
+            "get": {
+                "description": "Redirects the browser to the provider's login page using the authorization code flow with PKCE. The provider redirects back to /auth/{provider}/callback.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start login with an external provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "google",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the provider"
+                    },
+                    "404": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/impersonation/stop": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upload an image file to Cloudinary cloud storage",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "End the impersonation before the token expires. Must be called with the impersonation token itself; the token is revoked and an \"end\" entry is written to the audit log.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "uploads"
-                ],
-                "summary": "Upload file to Cloudinary",
-                "parameters": [
-                    {
-                        "type": "file",
-                        "description": "Image file to upload (max 10MB)",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Stop impersonating",
                 "responses": {
                     "200": {
-                        "description": "File uploaded successfully with URL and metadata",
+                        "description": "Impersonation stopped",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid file",
+                        "description": "Bad request - not an impersonation token",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -565,14 +698,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a list of all users",
+        "/login": {
+            "post": {
+                "description": "Authenticate user and return JWT token. When 2FA is enabled, returns an mfa_token to be exchanged at /login/mfa instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -580,21 +708,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "User login credentials",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.LoginRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all users",
                 "responses": {
                     "200": {
-                        "description": "List of users",
+                        "description": "Login successful",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
+                            "$ref": "#/definitions/docs.TokenResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Password accepted, second factor required",
+                        "schema": {
+                            "$ref": "#/definitions/docs.MFARequiredResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized - invalid token",
+                        "description": "Unauthorized - invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - email not verified",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see Retry-After header",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -606,14 +766,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/login/mfa": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Create a new user with provided data",
+                "description": "Exchange the mfa_token returned by /login and a TOTP or recovery code for access and refresh tokens",
                 "consumes": [
                     "application/json"
                 ],
@@ -621,25 +778,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Create a new user",
+                "summary": "Complete login with 2FA",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "mfa_token and TOTP or recovery code",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/docs.LoginMFARequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                    "200": {
+                        "description": "Login successful",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/docs.TokenResponse"
                         }
                     },
                     "400": {
@@ -649,7 +806,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Unauthorized - invalid token",
+                        "description": "Unauthorized - invalid token or code",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, see Retry-After header",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -663,14 +826,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/post": {
-            "get": {
+        "/logout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of all users including their posts",
+                "description": "Revoke the current access token and, if provided, the refresh token family",
                 "consumes": [
                     "application/json"
                 ],
@@ -678,15 +841,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
+                ],
+                "summary": "Logout user",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke",
+                        "name": "refresh_token",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/docs.LogoutRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all users with their posts",
                 "responses": {
                     "200": {
-                        "description": "List of users with their posts",
+                        "description": "Logout successful",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
                     "401": {
@@ -704,38 +885,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/me/api-keys": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get user details by user ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the API keys of the logged in user, including revoked ones. The key itself is never returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
-                ],
-                "summary": "Get a user by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "api-keys"
                 ],
+                "summary": "List my API keys",
                 "responses": {
                     "200": {
-                        "description": "User details",
+                        "description": "API keys",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/docs.APIKeyResponse"
+                            }
                         }
                     },
                     "401": {
@@ -744,8 +916,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage API keys",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -758,13 +930,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update user details by user ID",
+                "description": "Create a long-lived API key for machine clients. Scopes must be permissions of the user's role. The key is only shown once in this response; send it as ` + "`" + `X-API-Key: \u003ckey\u003e` + "`" + ` or ` + "`" + `Authorization: Bearer \u003ckey\u003e` + "`" + `.",
                 "consumes": [
                     "application/json"
                 ],
@@ -772,36 +944,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Update a user",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated user data",
-                        "name": "user",
+                        "description": "Name, scopes and optional expiry",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/docs.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User updated successfully",
+                    "201": {
+                        "description": "API key created",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/docs.APIKeyCreatedResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - validation error",
+                        "description": "Bad request - validation error or scope not allowed",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -812,8 +977,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage API keys",
                         "schema": {
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
@@ -825,22 +990,1211 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/me/api-keys/{id}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a user by user ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Revoke an API key. Requests using it are rejected immediately.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key revoked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage API keys",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the name and/or scopes of an active API key. Scopes replace the current list.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Rename or rescope an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name and/or scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.UpdateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key updated",
+                        "schema": {
+                            "$ref": "#/definitions/docs.APIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error, scope not allowed or key revoked",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage API keys",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the active logins (devices) of the logged in user. The session of the current token is marked with current=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List my sessions",
+                "responses": {
+                    "200": {
+                        "description": "Active sessions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/docs.SessionResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage sessions",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "End all my sessions except the one making this request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Log out everywhere else",
+                "responses": {
+                    "200": {
+                        "description": "Number of sessions ended",
+                        "schema": {
+                            "$ref": "#/definitions/docs.RevokeSessionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage sessions",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Log out one of my sessions. Its access and refresh tokens stop working immediately. Ending the current session is the same as logging out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "End a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session ended",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - API keys and impersonation tokens cannot manage sessions",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get the rate limit tier and today's usage of daily quotas for the logged in user. Daily quotas reset at 00:00 UTC.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get my quota usage",
+                "responses": {
+                    "200": {
+                        "description": "Quota usage",
+                        "schema": {
+                            "$ref": "#/definitions/docs.UsageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Send a single-use password reset link to the given email. Always responds with success so registered emails cannot be discovered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset link sent if the email is registered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Set a new password using a reset token. All existing sessions of the user are revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get a list of all posts with pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get all posts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of posts with pagination metadata",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Create a new post with provided data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Create a new post",
+                "parameters": [
+                    {
+                        "description": "Post data",
+                        "name": "post",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Post created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Daily quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/docs.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/posts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get post details by post ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get a post by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post details",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Update post details by post ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Update a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated post data",
+                        "name": "post",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the owner of the post",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Delete a post by post ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Delete a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Post deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not the owner of the post",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Generate new access token using refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "example": "\"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...\"",
+                        "description": "Refresh token",
+                        "name": "refresh_token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New tokens generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - expired or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register a new user with username, email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/docs.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error or username/email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Upload an image file to Cloudinary cloud storage",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Upload file to Cloudinary",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image file to upload (max 10MB)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File uploaded successfully with URL and metadata",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid file",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Daily quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/docs.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get a list of all users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "responses": {
+                    "200": {
+                        "description": "List of users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Create a new user with provided data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - insufficient permission",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/post": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get a list of all users including their posts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users with their posts",
+                "responses": {
+                    "200": {
+                        "description": "List of users with their posts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Get user details by user ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User details",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update user details by user ID. Users may update themselves, admins may update anyone. Only the user themselves may change the password and only admins may change roles. Changing the email marks it as unverified and sends a new verification link.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - not allowed to modify this user",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a user by user ID. Users may delete themselves, admins may delete anyone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
                 ],
                 "summary": "Delete a user",
                 "parameters": [
@@ -868,6 +2222,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden - not allowed to delete this user",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "User not found",
                         "schema": {
@@ -882,16 +2242,351 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "docs.ErrorResponse": {
-            "description": "Error response payload",
+        },
+        "/verify-email": {
+            "post": {
+                "description": "Mark the user's email as verified using the signed token from the verification link",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify email address",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/verify-email/resend": {
+            "post": {
+                "description": "Send a new verification link if the email is registered and not yet verified. Always responds with success so registered emails cannot be discovered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend verification email",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification link sent if applicable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - validation error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "docs.APIKeyCreatedResponse": {
+            "description": "Newly created API key. Store the key now, it cannot be shown again.",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-30T12:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-04-30T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "type": "string",
+                    "example": "pk_Ab3dE6gHxY..."
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-01-31T08:15:00Z"
+                },
+                "last_used_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci-deploy"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "pk_Ab3dE6gH"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write",
+                        "uploads:write"
+                    ]
+                }
+            }
+        },
+        "docs.APIKeyResponse": {
+            "description": "API key metadata. The key itself is only returned once at creation.",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-30T12:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-04-30T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-01-31T08:15:00Z"
+                },
+                "last_used_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci-deploy"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "pk_Ab3dE6gH"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write",
+                        "uploads:write"
+                    ]
+                }
+            }
+        },
+        "docs.CreateAPIKeyRequest": {
+            "description": "Create API key request payload. expires_in_days is optional, keys without it never expire.",
+            "type": "object",
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci-deploy"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write",
+                        "uploads:write"
+                    ]
+                }
+            }
+        },
+        "docs.ErrorResponse": {
+            "description": "Error response payload",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Invalid credentials"
+                }
+            }
+        },
+        "docs.ForgotPasswordRequest": {
+            "description": "Forgot password request payload",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                }
+            }
+        },
+        "docs.ImpersonateRequest": {
+            "description": "Impersonation request payload, the reason is stored in the audit log",
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Reproducing ticket #4821: posts not visible"
+                }
+            }
+        },
+        "docs.ImpersonatedUser": {
+            "description": "The user acted as by an impersonation token",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "docs.ImpersonationAuditResponse": {
+            "description": "One impersonation audit entry: the start or end of an impersonation, or a request made with its token",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "request"
+                },
+                "admin_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-30T12:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "method": {
+                    "type": "string",
+                    "example": "GET"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/posts?page=2"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Reproducing ticket #4821: posts not visible"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "token_id": {
+                    "type": "string",
+                    "example": "9b2d6c1e-3f4a-4e8b-a1c2-7d5e6f708192"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "docs.ImpersonationResponse": {
+            "description": "Short-lived access token acting as the user. There is no refresh token.",
             "type": "object",
             "properties": {
-                "error": {
+                "access_token": {
                     "type": "string",
-                    "example": "Invalid credentials"
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "impersonating": {
+                    "$ref": "#/definitions/docs.ImpersonatedUser"
+                },
+                "token_id": {
+                    "type": "string",
+                    "example": "9b2d6c1e-3f4a-4e8b-a1c2-7d5e6f708192"
+                }
+            }
+        },
+        "docs.LoginMFARequest": {
+            "description": "Second step of a 2FA login",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
                 }
             }
         },
@@ -909,6 +2604,129 @@ const docTemplate = `{
                 }
             }
         },
+        "docs.LogoutRequest": {
+            "description": "Logout request payload",
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "docs.MFARequiredResponse": {
+            "description": "Login response when two-factor authentication is required",
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "mfa_required": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "docs.OAuthProvidersResponse": {
+            "description": "External login providers that can be used at /auth/{provider}/login",
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "google",
+                        "gitlab"
+                    ]
+                }
+            }
+        },
+        "docs.QuotaExceededAt": {
+            "description": "Details of the exceeded quota",
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "posts:create"
+                },
+                "reset_at": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "docs.QuotaExceededResponse": {
+            "description": "Response when a daily quota is used up",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Daily quota exceeded"
+                },
+                "quota": {
+                    "$ref": "#/definitions/docs.QuotaExceededAt"
+                },
+                "retry_after": {
+                    "type": "integer",
+                    "example": 3600
+                }
+            }
+        },
+        "docs.QuotaUsage": {
+            "description": "Usage of one daily quota. limit and remaining are omitted for unlimited operations.",
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "posts:create"
+                },
+                "remaining": {
+                    "type": "integer",
+                    "example": 47
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "docs.RateLimitTier": {
+            "description": "Request rate allowed for the user's tier",
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "requests_per_minute": {
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
         "docs.RegisterRequest": {
             "description": "Register user request payload",
             "type": "object",
@@ -927,6 +2745,121 @@ const docTemplate = `{
                 }
             }
         },
+        "docs.ResendVerificationRequest": {
+            "description": "Resend verification email request payload",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                }
+            }
+        },
+        "docs.ResetPasswordRequest": {
+            "description": "Reset password request payload",
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q2Yt8xV1..."
+                }
+            }
+        },
+        "docs.RevokeSessionsResponse": {
+            "description": "Result of logging out everywhere else",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Logged out from all other sessions"
+                },
+                "revoked": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "docs.SessionResponse": {
+            "description": "One login session (device) of the user",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-30T12:00:00Z"
+                },
+                "current": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f1c2a7e-8d4b-4c55-9a0e-6b2f1d9c8e71"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "last_refreshed_at": {
+                    "type": "string",
+                    "example": "2025-01-31T08:15:00Z"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (X11; Linux x86_64)"
+                }
+            }
+        },
+        "docs.TOTPCodeRequest": {
+            "description": "TOTP code request payload",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "docs.TOTPDisableRequest": {
+            "description": "Disable 2FA request payload",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "docs.TOTPEnrollResponse": {
+            "description": "TOTP enrollment response payload",
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Final%20Project%20API:johndoe?secret=JBSWY3DPEHPK3PXP\u0026issuer=Final+Project+API"
+                },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abcde-fghij"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
         "docs.TokenResponse": {
             "description": "Token response payload",
             "type": "object",
@@ -945,6 +2878,84 @@ const docTemplate = `{
                 }
             }
         },
+        "docs.UnlockUserRequest": {
+            "description": "Admin unlock request payload",
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                }
+            }
+        },
+        "docs.UpdateAPIKeyRequest": {
+            "description": "Update API key request payload, all fields optional",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "ci-deploy"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "posts:write"
+                    ]
+                }
+            }
+        },
+        "docs.UpdateUserRequest": {
+            "description": "Update user request payload, all fields are optional",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "john@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "newpassword123"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "docs.UsageResponse": {
+            "description": "Quota usage of the logged in user",
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2025-01-31"
+                },
+                "quotas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/docs.QuotaUsage"
+                    }
+                },
+                "rate_limit": {
+                    "$ref": "#/definitions/docs.RateLimitTier"
+                },
+                "reset_at": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "user"
+                }
+            }
+        },
         "docs.UserResponse": {
             "description": "User response payload",
             "type": "object",
@@ -967,6 +2978,16 @@ const docTemplate = `{
                 }
             }
         },
+        "docs.VerifyEmailRequest": {
+            "description": "Verify email request payload",
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
         "gorm.DeletedAt": {
             "type": "object",
             "properties": {
@@ -981,6 +3002,10 @@ const docTemplate = `{
         },
         "models.Post": {
             "type": "object",
+            "required": [
+                "body",
+                "title"
+            ],
             "properties": {
                 "body": {
                     "type": "string"
@@ -1001,7 +3026,12 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "user": {
-                    "$ref": "#/definitions/models.User"
+                    "description": "tambahkan omitempty agar tidak divalidasi",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
                 },
                 "user_id": {
                     "type": "integer"
@@ -1025,6 +3055,9 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "email_verified_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1041,6 +3074,9 @@ const docTemplate = `{
                 "role": {
                     "type": "string"
                 },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
@@ -1051,6 +3087,12 @@ const docTemplate = `{
         }
     },
     "securityDefinitions": {
+        "APIKeyAuth": {
+            "description": "Personal API key created through /me/api-keys. Can also be sent as \"Bearer \u003ckey\u003e\".",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "description": "Type \"Bearer\" followed by a space and JWT token.",
             "type": "apiKey",
@@ -58,4 +58,227 @@ type PostResponse struct {
 // @Description Error response payload
 type ErrorResponse struct {
 	Error string `json:"error" example:"Invalid credentials"`
-}
\ No newline at end of file
+}
+// LogoutRequest model info
+// @Description Logout request payload
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// UpdateUserRequest model info
+// @Description Update user request payload, all fields are optional
+type UpdateUserRequest struct {
+	Username string `json:"username" example:"johndoe"`
+	Email    string `json:"email" example:"john@example.com"`
+	Password string `json:"password" example:"newpassword123"`
+	Role     string `json:"role" example:"user"`
+}
+
+// ForgotPasswordRequest model info
+// @Description Forgot password request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" example:"john@example.com"`
+}
+
+// ResetPasswordRequest model info
+// @Description Reset password request payload
+type ResetPasswordRequest struct {
+	Token    string `json:"token" example:"q2Yt8xV1..."`
+	Password string `json:"password" example:"newpassword123"`
+}
+
+// VerifyEmailRequest model info
+// @Description Verify email request payload
+type VerifyEmailRequest struct {
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// ResendVerificationRequest model info
+// @Description Resend verification email request payload
+type ResendVerificationRequest struct {
+	Email string `json:"email" example:"john@example.com"`
+}
+
+// MFARequiredResponse model info
+// @Description Login response when two-factor authentication is required
+type MFARequiredResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn   int    `json:"expires_in" example:"300"`
+}
+
+// LoginMFARequest model info
+// @Description Second step of a 2FA login
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" example:"123456"`
+}
+
+// TOTPEnrollResponse model info
+// @Description TOTP enrollment response payload
+type TOTPEnrollResponse struct {
+	OTPAuthURI    string   `json:"otpauth_uri" example:"otpauth://totp/Final%20Project%20API:johndoe?secret=JBSWY3DPEHPK3PXP&issuer=Final+Project+API"`
+	Secret        string   `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-fghij"`
+}
+
+// TOTPCodeRequest model info
+// @Description TOTP code request payload
+type TOTPCodeRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+// TOTPDisableRequest model info
+// @Description Disable 2FA request payload
+type TOTPDisableRequest struct {
+	Password string `json:"password" example:"password123"`
+	Code     string `json:"code" example:"123456"`
+}
+
+// UnlockUserRequest model info
+// @Description Admin unlock request payload
+type UnlockUserRequest struct {
+	IP string `json:"ip,omitempty" example:"203.0.113.7"`
+}
+
+// QuotaUsage model info
+// @Description Usage of one daily quota. limit and remaining are omitted for unlimited operations.
+type QuotaUsage struct {
+	Operation string `json:"operation" example:"posts:create"`
+	Used      int    `json:"used" example:"3"`
+	Limit     int    `json:"limit,omitempty" example:"50"`
+	Remaining int    `json:"remaining,omitempty" example:"47"`
+}
+
+// RateLimitTier model info
+// @Description Request rate allowed for the user's tier
+type RateLimitTier struct {
+	RequestsPerMinute int `json:"requests_per_minute" example:"60"`
+	Burst             int `json:"burst" example:"20"`
+}
+
+// UsageResponse model info
+// @Description Quota usage of the logged in user
+type UsageResponse struct {
+	Tier      string        `json:"tier" example:"user"`
+	RateLimit RateLimitTier `json:"rate_limit"`
+	Date      string        `json:"date" example:"2025-01-31"`
+	ResetAt   string        `json:"reset_at" example:"2025-02-01T00:00:00Z"`
+	Quotas    []QuotaUsage  `json:"quotas"`
+}
+
+// QuotaExceededResponse model info
+// @Description Response when a daily quota is used up
+type QuotaExceededResponse struct {
+	Error      string          `json:"error" example:"Daily quota exceeded"`
+	Quota      QuotaExceededAt `json:"quota"`
+	RetryAfter int             `json:"retry_after" example:"3600"`
+}
+
+// QuotaExceededAt model info
+// @Description Details of the exceeded quota
+type QuotaExceededAt struct {
+	Operation string `json:"operation" example:"posts:create"`
+	Tier      string `json:"tier" example:"user"`
+	Limit     int    `json:"limit" example:"50"`
+	Used      int    `json:"used" example:"50"`
+	ResetAt   string `json:"reset_at" example:"2025-02-01T00:00:00Z"`
+}
+
+// CreateAPIKeyRequest model info
+// @Description Create API key request payload. expires_in_days is optional, keys without it never expire.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" example:"ci-deploy"`
+	Scopes        []string `json:"scopes" example:"posts:write,uploads:write"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty" example:"90"`
+}
+
+// UpdateAPIKeyRequest model info
+// @Description Update API key request payload, all fields optional
+type UpdateAPIKeyRequest struct {
+	Name   string   `json:"name,omitempty" example:"ci-deploy"`
+	Scopes []string `json:"scopes,omitempty" example:"posts:write"`
+}
+
+// APIKeyResponse model info
+// @Description API key metadata. The key itself is only returned once at creation.
+type APIKeyResponse struct {
+	ID         uint     `json:"id" example:"1"`
+	Name       string   `json:"name" example:"ci-deploy"`
+	Prefix     string   `json:"prefix" example:"pk_Ab3dE6gH"`
+	Scopes     []string `json:"scopes" example:"posts:write,uploads:write"`
+	ExpiresAt  string   `json:"expires_at,omitempty" example:"2025-04-30T12:00:00Z"`
+	LastUsedAt string   `json:"last_used_at,omitempty" example:"2025-01-31T08:15:00Z"`
+	LastUsedIP string   `json:"last_used_ip,omitempty" example:"203.0.113.7"`
+	RevokedAt  string   `json:"revoked_at,omitempty" example:"2025-02-01T00:00:00Z"`
+	CreatedAt  string   `json:"created_at" example:"2025-01-30T12:00:00Z"`
+}
+
+// APIKeyCreatedResponse model info
+// @Description Newly created API key. Store the key now, it cannot be shown again.
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"pk_Ab3dE6gHxY..."`
+}
+
+// SessionResponse model info
+// @Description One login session (device) of the user
+type SessionResponse struct {
+	ID              string `json:"id" example:"3f1c2a7e-8d4b-4c55-9a0e-6b2f1d9c8e71"`
+	UserAgent       string `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IP              string `json:"ip" example:"203.0.113.7"`
+	CreatedAt       string `json:"created_at" example:"2025-01-30T12:00:00Z"`
+	LastRefreshedAt string `json:"last_refreshed_at,omitempty" example:"2025-01-31T08:15:00Z"`
+	Current         bool   `json:"current" example:"true"`
+}
+
+// RevokeSessionsResponse model info
+// @Description Result of logging out everywhere else
+type RevokeSessionsResponse struct {
+	Message string `json:"message" example:"Logged out from all other sessions"`
+	Revoked int    `json:"revoked" example:"2"`
+}
+
+// OAuthProvidersResponse model info
+// @Description External login providers that can be used at /auth/{provider}/login
+type OAuthProvidersResponse struct {
+	Providers []string `json:"providers" example:"google,gitlab"`
+}
+
+// ImpersonateRequest model info
+// @Description Impersonation request payload, the reason is stored in the audit log
+type ImpersonateRequest struct {
+	Reason string `json:"reason" example:"Reproducing ticket #4821: posts not visible"`
+}
+
+// ImpersonatedUser model info
+// @Description The user acted as by an impersonation token
+type ImpersonatedUser struct {
+	ID       uint   `json:"id" example:"42"`
+	Username string `json:"username" example:"johndoe"`
+}
+
+// ImpersonationResponse model info
+// @Description Short-lived access token acting as the user. There is no refresh token.
+type ImpersonationResponse struct {
+	AccessToken   string           `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenID       string           `json:"token_id" example:"9b2d6c1e-3f4a-4e8b-a1c2-7d5e6f708192"`
+	ExpiresIn     int              `json:"expires_in" example:"900"`
+	Impersonating ImpersonatedUser `json:"impersonating"`
+}
+
+// ImpersonationAuditResponse model info
+// @Description One impersonation audit entry: the start or end of an impersonation, or a request made with its token
+type ImpersonationAuditResponse struct {
+	ID        uint   `json:"id" example:"1"`
+	AdminID   uint   `json:"admin_id" example:"1"`
+	UserID    uint   `json:"user_id" example:"42"`
+	TokenID   string `json:"token_id" example:"9b2d6c1e-3f4a-4e8b-a1c2-7d5e6f708192"`
+	Action    string `json:"action" example:"request"`
+	Reason    string `json:"reason,omitempty" example:"Reproducing ticket #4821: posts not visible"`
+	Method    string `json:"method" example:"GET"`
+	Path      string `json:"path" example:"/posts?page=2"`
+	Status    int    `json:"status" example:"200"`
+	IP        string `json:"ip" example:"203.0.113.7"`
+	CreatedAt string `json:"created_at" example:"2025-01-30T12:00:00Z"`
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"final/config"
+	"fmt"
+	"sync"
+)
+
+// Message adalah email yang akan dikirim
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer adalah abstraksi pengiriman email
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var (
+	defaultMailer Mailer = NewLogMailer()
+	mutex         sync.RWMutex
+)
+
+// SetDefault mengganti mailer yang digunakan aplikasi
+func SetDefault(m Mailer) {
+	mutex.Lock()
+	defaultMailer = m
+	mutex.Unlock()
+}
+
+// Default mengembalikan mailer yang digunakan aplikasi
+func Default() Mailer {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return defaultMailer
+}
+
+// Send mengirim email menggunakan mailer default
+func Send(msg Message) error {
+	return Default().Send(msg)
+}
+
+// FromConfig membuat mailer sesuai konfigurasi MAIL_DRIVER
+func FromConfig() (Mailer, error) {
+	switch config.MailDriver() {
+	case "smtp":
+		if config.SMTPHost() == "" {
+			return nil, fmt.Errorf("SMTP_HOST harus diatur untuk MAIL_DRIVER=smtp")
+		}
+		return NewSMTPMailer(config.SMTPHost(), config.SMTPPort(), config.SMTPUsername(), config.SMTPPassword(), config.MailFrom()), nil
+	case "file":
+		return NewFileMailer(config.MailFilePath()), nil
+	case "log":
+		// Driver log menulis link dan token reset ke log aplikasi, hanya untuk development
+		if !config.IsDevelopment() {
+			return nil, fmt.Errorf("MAIL_DRIVER=log hanya boleh dipakai di mode development, atur MAIL_DRIVER=smtp")
+		}
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("MAIL_DRIVER tidak dikenal: %s", config.MailDriver())
+	}
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer tidak mengirim email, hanya menulis isinya ke log (untuk development)
+type LogMailer struct{}
+
+// NewLogMailer membuat mailer yang menulis ke log
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send menulis email ke log
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer menambahkan setiap email ke sebuah file (untuk testing offline)
+type FileMailer struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewFileMailer membuat mailer yang menulis ke file
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send menambahkan email ke akhir file
+func (m *FileMailer) Send(msg Message) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n---\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFileMailer tests that messages are appended to the sink file
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path)
+
+	assert.Nil(t, m.Send(Message{To: "a@example.com", Subject: "Pertama", Body: "link-1"}))
+	assert.Nil(t, m.Send(Message{To: "b@example.com", Subject: "Kedua", Body: "link-2"}))
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "To: a@example.com")
+	assert.Contains(t, string(content), "Subject: Kedua")
+	assert.Contains(t, string(content), "link-2")
+}
+
+// TestBuildMessageStripsHeaderInjection tests that newlines cannot inject extra headers
+func TestBuildMessageStripsHeaderInjection(t *testing.T) {
+	raw := string(buildMessage("no-reply@example.com", Message{
+		To:      "a@example.com\r\nBcc: attacker@example.com",
+		Subject: "Halo",
+		Body:    "isi",
+	}))
+
+	assert.NotContains(t, raw, "\r\nBcc:")
+}
+
+// TestFromConfigRejectsLogDriverOutsideDevelopment tests that reset links are never logged in production
+func TestFromConfigRejectsLogDriverOutsideDevelopment(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "")
+
+	t.Setenv("APP_ENV", "production")
+	_, err := FromConfig()
+	assert.NotNil(t, err)
+
+	t.Setenv("APP_ENV", "development")
+	m, err := FromConfig()
+	assert.Nil(t, err)
+	assert.IsType(t, &LogMailer{}, m)
+}
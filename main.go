@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	database "final/config"
 	"final/controllers"
+	"final/mailer"
 	"final/routes"
+	"final/utils"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "final/docs" // Import docs untuk Swagger
 
@@ -32,6 +40,11 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description Personal API key created through /me/api-keys. Can also be sent as "Bearer <key>".
+
 func main() {
 	// Load .env file
 	err := godotenv.Load()
@@ -49,6 +62,23 @@ func main() {
 		log.Fatal("Error: CLOUDINARY_URL tidak ditemukan. Silakan atur CLOUDINARY_URL di Railway dashboard")
 	}
 
+	// Tolak secret JWT default di luar mode development
+	if err := database.ValidateJWTSecret(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Kunci enkripsi data sensitif harus terpisah dari secret JWT di luar mode development
+	if err := database.ValidateEncryptionKey(); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Kunci untuk menandatangani dan memverifikasi JWT
+	keyring, err := utils.LoadKeyringFromConfig()
+	if err != nil {
+		log.Fatalf("Gagal memuat kunci JWT: %v", err)
+	}
+	utils.SetKeyring(keyring)
+
 	// Inisialisasi Cloudinary
 	controllers.InitCloudinary()
 
@@ -56,12 +86,67 @@ func main() {
 	database.ConnectDatabase()
 	// Migrasi database sudah dilakukan di ConnectDatabase()
 
+	// Redis untuk rate limit dan cache yang dibagi antar replika
+	if database.RateLimitBackend() == "redis" || database.CacheBackend() == "redis" {
+		database.ConnectRedis()
+	}
+
+	// Store untuk token yang dicabut (logout)
+	if database.TokenStoreBackend() == "memory" {
+		utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+	} else {
+		utils.SetRevocationStore(utils.NewDBRevocationStore(database.DB))
+	}
+	// Goroutine latar belakang berhenti saat server dimatikan (SIGINT/SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	utils.StartRevocationPruner(ctx, time.Hour)
+
+	// Cache verifikasi JWT yang dibagi oleh semua AuthMiddleware
+	tokenCache := utils.NewTokenCache(database.TokenCacheMaxEntries())
+	tokenCache.Start(ctx, 5*time.Minute)
+	utils.SetTokenCache(tokenCache)
+
+	// Store untuk penghitung login gagal (lockout)
+	if database.LoginAttemptStoreBackend() == "memory" {
+		utils.SetLoginAttemptStore(utils.NewMemoryLoginAttemptStore())
+	} else {
+		utils.SetLoginAttemptStore(utils.NewDBLoginAttemptStore(database.DB))
+	}
+	utils.StartLoginAttemptPruner(ctx, 10*time.Minute)
+
+	// Mailer untuk email reset password
+	m, err := mailer.FromConfig()
+	if err != nil {
+		log.Fatalf("Gagal mengatur mailer: %v", err)
+	}
+	mailer.SetDefault(m)
+
+	// Penyedia login OpenID Connect dari OAUTH_PROVIDERS
+	for _, provider := range database.OAuthProviders() {
+		utils.SetOAuthProvider(utils.NewOIDCProvider(provider, nil))
+	}
+
 	// Setup router
 	r := routes.SetupRouter()
 
 	// Jalankan server di port 8080
-	log.Println("Server berjalan di http://localhost:8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Gagal menjalankan server: %v", err)
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Println("Server berjalan di http://localhost:8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Gagal menjalankan server: %v", err)
+		}
+	}()
+
+	// Tunggu sinyal shutdown lalu beri waktu request yang sedang berjalan untuk selesai
+	<-ctx.Done()
+	stop()
+	log.Println("Mematikan server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Gagal mematikan server dengan bersih: %v", err)
 	}
 }
@@ -1,43 +1,141 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
+	"final/config"
 	"final/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-// AuthMiddleware untuk validasi JWT dengan caching
-func AuthMiddleware() gin.HandlerFunc {
-	// Cache untuk menyimpan token yang valid (token -> username)
-	tokenCache := make(map[string]string)
-	// Cache untuk menyimpan token yang tidak valid (untuk rate limiting)
-	invalidTokens := make(map[string]time.Time)
-	// Mutex untuk mengamankan akses ke cache
-	var mutex sync.Mutex
-	// Cleanup interval untuk invalid tokens (10 menit)
-	cleanupInterval := 10 * time.Minute
-	
-	// Goroutine untuk membersihkan token tidak valid yang sudah lama
-	go func() {
-		for {
-			time.Sleep(cleanupInterval)
-			now := time.Now()
-			mutex.Lock()
-			for token, timestamp := range invalidTokens {
-				if now.Sub(timestamp) > cleanupInterval {
-					delete(invalidTokens, token)
-				}
-			}
-			mutex.Unlock()
+// abortIfRevoked menghentikan request jika token sudah dicabut (misalnya setelah logout)
+func abortIfRevoked(c *gin.Context, claims *jwt.MapClaims) bool {
+	revoked, err := utils.IsTokenRevoked(claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Gagal memeriksa status token",
+		})
+		c.Abort()
+		return true
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  http.StatusUnauthorized,
+			"message": "Token sudah dicabut",
+		})
+		c.Abort()
+		return true
+	}
+	return false
+}
+
+// apiKeyFromRequest mengambil API key dari header X-API-Key atau Authorization: Bearer pk_...
+func apiKeyFromRequest(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	if token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); utils.IsAPIKey(token) {
+		return token
+	}
+	return ""
+}
+
+// authenticateAPIKey memvalidasi API key dan mengisi context seperti token JWT
+// Role diambil dari data user saat ini, scope key membatasi permission yang bisa dipakai
+func authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, user, err := utils.AuthenticateAPIKey(config.DB, key, c.ClientIP())
+	if errors.Is(err, utils.ErrAPIKeyInvalid) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  http.StatusUnauthorized,
+			"message": "API key tidak valid, sudah dicabut atau kedaluwarsa",
+		})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Gagal memeriksa API key",
+		})
+		c.Abort()
+		return
+	}
+
+	SetCurrentUser(c, &utils.CurrentUser{
+		User:     user,
+		APIKeyID: apiKey.ID,
+		Scopes:   utils.ParseScopes(apiKey.Scopes),
+	})
+	c.Next()
+}
+
+// authenticateToken memuat user dari claim sub dan menolak token yang versinya
+// sudah tidak sama dengan users.token_version (lihat utils.BumpTokenVersion)
+func authenticateToken(c *gin.Context, verified *utils.VerifiedToken) {
+	user, err := loadUser(verified.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.TokenVersion != verified.TokenVersion) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  http.StatusUnauthorized,
+			"message": "Token sudah tidak berlaku, silakan login ulang",
+		})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Gagal memuat data user",
+		})
+		c.Abort()
+		return
+	}
+
+	sessionID, _ := (*verified.Claims)["sid"].(string)
+	current := &utils.CurrentUser{User: user, SessionID: sessionID, Claims: verified.Claims}
+
+	// Token impersonasi hanya berlaku selama admin masih ada, tokennya belum dicabut
+	// dan masih boleh meniru user tersebut
+	adminID, adminVersion, impersonated, err := utils.TokenActor(verified.Claims)
+	if impersonated {
+		admin, loadErr := loadUser(adminID)
+		if err != nil || loadErr != nil || admin.TokenVersion != adminVersion || !utils.CanImpersonate(admin, user) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  http.StatusUnauthorized,
+				"message": "Token impersonasi sudah tidak berlaku",
+			})
+			c.Abort()
+			return
 		}
-	}()
-	
+		current.Impersonator = &admin
+	}
+
+	SetCurrentUser(c, current)
+	if current.IsImpersonated() {
+		auditImpersonation(c, current)
+		return
+	}
+	c.Next()
+}
+
+// AuthMiddleware untuk validasi JWT dengan caching, atau API key dari header
+// X-API-Key maupun Authorization: Bearer pk_...
+// Semua instance memakai cache verifikasi yang sama (utils.GetTokenCache)
+// User dimuat sekali per request dan bisa diambil handler lewat CurrentUser
+func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API key tidak di-cache agar pencabutan langsung berlaku
+		if apiKey := apiKeyFromRequest(c); apiKey != "" {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		// Ambil token dari header
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
@@ -50,13 +148,12 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		
-		// Cek apakah token ada di daftar invalid
-		mutex.Lock()
-		_, isInvalid := invalidTokens[tokenString]
-		mutex.Unlock()
-		
-		if isInvalid {
+		tokenCache := utils.GetTokenCache()
+
+		// Cek cache terlebih dahulu, entry dibuang otomatis saat token kedaluwarsa
+		cached, exists := tokenCache.Get(tokenString)
+		if exists && cached == nil {
+			// Token ada di daftar invalid
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  http.StatusUnauthorized,
 				"message": "Token tidak valid atau sudah kedaluwarsa",
@@ -64,16 +161,13 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		
-		// Cek cache terlebih dahulu
-		mutex.Lock()
-		username, exists := tokenCache[tokenString]
-		mutex.Unlock()
-		
+
 		if exists {
-			// Token ada di cache, set username ke context
-			c.Set("username", username)
-			c.Next()
+			// Token ada di cache, tetap cek apakah sudah dicabut
+			if abortIfRevoked(c, cached.Claims) {
+				return
+			}
+			authenticateToken(c, cached)
 			return
 		}
 
@@ -81,10 +175,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		claims, err := utils.ParseJWT(tokenString)
 		if err != nil {
 			// Tambahkan token ke daftar invalid
-			mutex.Lock()
-			invalidTokens[tokenString] = time.Now()
-			mutex.Unlock()
-			
+			tokenCache.SetInvalid(tokenString, config.TokenCacheInvalidTTL())
+
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status":  http.StatusUnauthorized,
 				"message": "Token tidak valid",
@@ -95,12 +187,11 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Verifikasi bahwa token belum kedaluwarsa
-		if exp, ok := (*claims)["exp"].(float64); ok {
-			if time.Now().Unix() > int64(exp) {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-				c.Abort()
-				return
-			}
+		exp, hasExp := (*claims)["exp"].(float64)
+		if hasExp && time.Now().Unix() > int64(exp) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+			c.Abort()
+			return
 		}
 
 		// Verifikasi bahwa ini adalah access token bukan refresh token
@@ -110,21 +201,26 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Set username untuk digunakan dalam handler
-		username, ok := (*claims)["username"].(string)
-		if !ok {
+		// User dikenali dari claim sub, token lama yang hanya membawa username ditolak
+		userID, err := utils.TokenUserID(claims)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
 			c.Abort()
 			return
 		}
 
-		c.Set("username", username)
-		
-		// Simpan token di cache
-		mutex.Lock()
-		tokenCache[tokenString] = username
-		mutex.Unlock()
-		
-		c.Next()
+		// Verifikasi bahwa token belum dicabut
+		if abortIfRevoked(c, claims) {
+			return
+		}
+
+		verified := utils.VerifiedToken{UserID: userID, TokenVersion: utils.TokenVersion(claims), Claims: claims}
+
+		// Simpan token di cache sampai waktu kedaluwarsanya, token tanpa exp selalu diverifikasi ulang
+		if hasExp {
+			tokenCache.Set(tokenString, verified, time.Unix(int64(exp), 0))
+		}
+
+		authenticateToken(c, &verified)
 	}
 }
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"final/models"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// testUserIDs memberi ID tetap untuk user di test middleware
+var testUserIDs = map[string]uint{"alice": 1, "bob": 2, "carol": 3}
+
+// testUser membuat CurrentUser untuk test tanpa database
+func testUser(username, role string) *utils.CurrentUser {
+	return &utils.CurrentUser{User: models.User{
+		Model:    gorm.Model{ID: testUserIDs[username]},
+		Username: username,
+		Role:     role,
+	}}
+}
+
+// stubUsers mengganti loadUser dengan user di memori selama test
+func stubUsers(t *testing.T, users ...models.User) {
+	original := loadUser
+	t.Cleanup(func() { loadUser = original })
+	loadUser = func(id uint) (models.User, error) {
+		for _, user := range users {
+			if user.ID == id {
+				return user, nil
+			}
+		}
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+}
+
+// TestAuthMiddlewareSharedCache tests that every AuthMiddleware instance uses the shared token cache
+func TestAuthMiddlewareSharedCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := utils.NewTokenCache(100)
+	utils.SetTokenCache(cache)
+	defer utils.SetTokenCache(utils.NewTokenCache(100))
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+
+	alice := testUser("alice", utils.RoleAdmin).User
+	stubUsers(t, alice)
+
+	r := gin.New()
+	r.GET("/users", AuthMiddleware(), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("role")) })
+	r.GET("/admin", AuthMiddleware(), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("role")) })
+
+	tokens, err := utils.GenerateJWT(utils.TokenSubject{UserID: alice.ID, Role: utils.RoleAdmin})
+	assert.Nil(t, err)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusOK, get("/users", tokens.AccessToken).Code)
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, utils.RoleAdmin, get("/admin", tokens.AccessToken).Body.String())
+	assert.Equal(t, 1, cache.Len())
+
+	// Token tidak valid juga dicatat di cache yang sama
+	assert.Equal(t, http.StatusUnauthorized, get("/users", "garbage").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("/admin", "garbage").Code)
+	assert.Equal(t, 2, cache.Len())
+
+	utils.InvalidateCachedUserTokens(alice.ID)
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, http.StatusOK, get("/admin", tokens.AccessToken).Code)
+}
+
+// TestAuthMiddlewareCurrentUser tests that the user is loaded by ID once per request,
+// survives a rename, and that bumping the token version rejects existing tokens
+func TestAuthMiddlewareCurrentUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.SetTokenCache(utils.NewTokenCache(100))
+	defer utils.SetTokenCache(utils.NewTokenCache(100))
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+
+	bob := testUser("bob", utils.RoleUser).User
+	stubUsers(t, bob)
+	loads := 0
+	load := loadUser
+	loadUser = func(id uint) (models.User, error) {
+		loads++
+		return load(id)
+	}
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		assert.True(t, ok)
+		c.String(http.StatusOK, user.Username+" "+user.Role)
+	})
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	tokens, err := utils.GenerateJWT(utils.TokenSubject{UserID: bob.ID, Role: utils.RoleUser})
+	assert.Nil(t, err)
+	assert.Equal(t, "bob user", get(tokens.AccessToken).Body.String())
+	assert.Equal(t, 1, loads)
+
+	// Username dan role diambil dari data user saat ini, bukan dari token
+	bob.Username, bob.Role = "robert", utils.RoleAdmin
+	stubUsers(t, bob)
+	assert.Equal(t, "robert admin", get(tokens.AccessToken).Body.String())
+
+	// Token version dinaikkan, token lama ditolak walaupun masih di cache
+	bob.TokenVersion++
+	stubUsers(t, bob)
+	assert.Equal(t, http.StatusUnauthorized, get(tokens.AccessToken).Code)
+
+	renewed, _ := utils.GenerateJWT(utils.TokenSubject{UserID: bob.ID, Role: bob.Role, TokenVersion: bob.TokenVersion})
+	assert.Equal(t, http.StatusOK, get(renewed.AccessToken).Code)
+
+	// User yang dihapus tidak bisa memakai tokennya
+	stubUsers(t)
+	assert.Equal(t, http.StatusUnauthorized, get(renewed.AccessToken).Code)
+
+	// Token lama yang hanya membawa username ditolak
+	legacy, _ := utils.GetKeyring().Sign(jwt.MapClaims{"username": "bob", "type": "access", "exp": time.Now().Add(time.Hour).Unix()})
+	assert.Equal(t, http.StatusUnauthorized, get(legacy).Code)
+}
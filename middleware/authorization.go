@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+
+	"final/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission memastikan role user memiliki semua permission yang diminta
+// dan, untuk request dengan API key, permission tersebut ada di scope key
+// Harus dipasang setelah AuthMiddleware karena membaca role dari context
+func RequirePermission(permissions ...utils.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  http.StatusUnauthorized,
+				"message": "User tidak terautentikasi",
+			})
+			c.Abort()
+			return
+		}
+
+		// Request dengan API key juga dibatasi scope key
+		scopes, _ := c.Get("scopes")
+		keyScopes, _ := scopes.([]utils.Permission)
+		for _, permission := range permissions {
+			if !utils.HasPermission(role, permission) || !utils.ScopesAllow(keyScopes, permission) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"status":     http.StatusForbidden,
+					"message":    "Akses ditolak",
+					"permission": permission,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession menolak request yang diautentikasi dengan API key atau token impersonasi
+// Dipakai untuk endpoint pengelolaan akun (password, 2FA, API key, hapus akun) agar key
+// yang bocor tidak bisa dipakai untuk mengambil alih akun dan admin yang meniru user
+// tidak bisa mengubah kredensial user tersebut
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, viaAPIKey := c.Get("api_key_id"); viaAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  http.StatusForbidden,
+				"message": "Endpoint ini tidak bisa diakses dengan API key",
+			})
+			c.Abort()
+			return
+		}
+		if user, ok := CurrentUser(c); ok && user.IsImpersonated() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  http.StatusForbidden,
+				"message": "Endpoint ini tidak bisa diakses saat impersonasi",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequirePermission tests that only roles holding the permission reach the handler
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		role   string
+		status int
+	}{
+		{utils.RoleAdmin, http.StatusOK},
+		{utils.RoleUser, http.StatusForbidden},
+		{"", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		r := gin.New()
+		r.POST("/admin/cache/clear", func(c *gin.Context) {
+			if tc.role != "" {
+				c.Set("role", tc.role)
+			}
+			c.Next()
+		}, RequirePermission(utils.PermCacheManage), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/admin/cache/clear", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, tc.status, resp.Code, "role %q", tc.role)
+	}
+}
+
+// TestRequirePermissionWithScopes tests that API key scopes narrow the role's permissions
+func TestRequirePermissionWithScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		scopes []utils.Permission
+		status int
+	}{
+		{nil, http.StatusOK},
+		{[]utils.Permission{utils.PermCacheManage}, http.StatusOK},
+		{[]utils.Permission{utils.PermPostsWrite}, http.StatusForbidden},
+		{[]utils.Permission{}, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		r := gin.New()
+		r.POST("/admin/cache/clear", func(c *gin.Context) {
+			c.Set("role", utils.RoleAdmin)
+			if tc.scopes != nil {
+				c.Set("api_key_id", uint(1))
+				c.Set("scopes", tc.scopes)
+			}
+			c.Next()
+		}, RequirePermission(utils.PermCacheManage), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/admin/cache/clear", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, tc.status, resp.Code, "scopes %v", tc.scopes)
+	}
+}
+
+// TestRequireSession tests that requests authenticated with an API key are rejected
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, viaAPIKey := range []bool{false, true} {
+		r := gin.New()
+		r.POST("/2fa/disable", func(c *gin.Context) {
+			c.Set("role", utils.RoleUser)
+			if viaAPIKey {
+				c.Set("api_key_id", uint(1))
+			}
+			c.Next()
+		}, RequireSession(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/2fa/disable", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		expected := http.StatusOK
+		if viaAPIKey {
+			expected = http.StatusForbidden
+		}
+		assert.Equal(t, expected, resp.Code, "api key %v", viaAPIKey)
+	}
+}
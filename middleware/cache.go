@@ -2,82 +2,146 @@ package middleware
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
+	"final/utils"
+	"log"
 	"net/http"
-	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
 )
 
-// Item struktur untuk menyimpan item cache
-type cacheItem struct {
-	Content    []byte
-	Expiration time.Time
+// ResponseCache menyimpan respons GET agar bisa dipakai ulang selama masa berlakunya
+// Satu instance dibagi antara middleware dan endpoint admin untuk menghapus cache
+// Penyimpanan dilakukan oleh utils.CacheStore (memori atau Redis)
+type ResponseCache struct {
+	store      utils.CacheStore
+	expiration time.Duration
+	mutex      sync.Mutex
+	// flights menggabungkan request bersamaan untuk key yang sama saat cache kosong
+	flights singleflight.Group
+	// revalidating mencatat key yang sedang diperbarui di jendela stale-while-revalidate
+	revalidating map[string]bool
+	// Statistik cache sejak server berjalan, lihat Stats
+	routes        map[string]*CacheRouteStats
+	coalesced     int64
+	invalidations int64
 }
 
-// Cache middleware untuk menyimpan respons API
-func CacheMiddleware(expiration time.Duration) gin.HandlerFunc {
-	// Cache untuk menyimpan respons
-	cache := make(map[string]cacheItem)
-	// Mutex untuk mengamankan akses ke cache
-	var mutex sync.Mutex
-	// Jumlah maksimum item dalam cache
-	maxCacheItems := 1000
+// NewResponseCache membuat cache respons dan menjalankan pembersihan berkala
+func NewResponseCache(store utils.CacheStore, expiration time.Duration) *ResponseCache {
+	rc := &ResponseCache{
+		store:        store,
+		expiration:   expiration,
+		revalidating: make(map[string]bool),
+		routes:       make(map[string]*CacheRouteStats),
+	}
 	// Interval pembersihan cache (5 menit)
-	cleanupInterval := 5 * time.Minute
-	
-	// Goroutine untuk membersihkan cache yang sudah kedaluwarsa
-	go func() {
-		for {
-			time.Sleep(cleanupInterval)
-			now := time.Now()
-			mutex.Lock()
-			
-			// Hapus item yang sudah kedaluwarsa
-			for k, v := range cache {
-				if now.After(v.Expiration) {
-					delete(cache, k)
-				}
-			}
-			
-			// Jika cache masih terlalu besar, hapus 20% item tertua
-			if len(cache) > maxCacheItems {
-				// Buat slice untuk menyimpan key dan waktu kedaluwarsa
-				items := make([]struct {
-					key string
-					exp time.Time
-				}, 0, len(cache))
-				
-				for k, v := range cache {
-					items = append(items, struct {
-						key string
-						exp time.Time
-					}{k, v.Expiration})
-				}
-				
-				// Urutkan berdasarkan waktu kedaluwarsa (tertua dulu)
-				sort.Slice(items, func(i, j int) bool {
-					return items[i].exp.Before(items[j].exp)
-				})
-				
-				// Hapus 20% item tertua
-				toRemove := len(cache) / 5
-				for i := 0; i < toRemove; i++ {
-					delete(cache, items[i].key)
-				}
-			}
-			
-			mutex.Unlock()
-		}
-	}()
+	go rc.cleanupLoop(5 * time.Minute)
+	return rc
+}
+
+// cleanupLoop membersihkan cache yang sudah kedaluwarsa
+func (rc *ResponseCache) cleanupLoop(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		rc.cleanup()
+	}
+}
+
+// cleanup menghapus item kedaluwarsa dan catatan invalidasi yang sudah tidak dibutuhkan
+func (rc *ResponseCache) cleanup() {
+	if err := rc.store.Prune(); err != nil {
+		log.Printf("Gagal membersihkan cache: %v", err)
+	}
+}
+
+// Len mengembalikan jumlah item di cache
+func (rc *ResponseCache) Len() (int, error) {
+	return rc.store.Len()
+}
+
+// Clear menghapus semua item dan mengembalikan jumlah item yang dihapus
+func (rc *ResponseCache) Clear() (int, error) {
+	return rc.countInvalidations(rc.store.Clear())
+}
+
+// ClearPrefix menghapus item yang path-nya diawali prefix, contoh "/posts"
+func (rc *ResponseCache) ClearPrefix(prefix string) (int, error) {
+	return rc.countInvalidations(rc.store.ClearPrefix(prefix))
+}
+
+// ClearUser menghapus semua item yang berisi data satu user (tag utils.UserTag),
+// termasuk respons per user dari route dengan Vary: Authorization
+func (rc *ResponseCache) ClearUser(userID uint) (int, error) {
+	return rc.countInvalidations(rc.store.InvalidateTags(utils.UserTag(userID)))
+}
+
+// InvalidateTags menghapus semua item yang memiliki salah satu tag
+// dan mengembalikan jumlah item yang dihapus
+func (rc *ResponseCache) InvalidateTags(tags ...string) int {
+	evicted, err := rc.countInvalidations(rc.store.InvalidateTags(tags...))
+	if err != nil {
+		log.Printf("Gagal menghapus cache dengan tag %v: %v", tags, err)
+	}
+	return evicted
+}
+
+// epoch mengambil nomor invalidasi dari store sebelum handler dijalankan
+// Mengembalikan -1 jika store gagal sehingga respons tidak disimpan
+func (rc *ResponseCache) epoch() int64 {
+	epoch, err := rc.store.Epoch()
+	if err != nil {
+		log.Printf("Gagal membaca epoch cache: %v", err)
+		return -1
+	}
+	return epoch
+}
+
+// save menyimpan item kecuali salah satu tag-nya dihapus setelah epoch diambil, juga oleh
+// replika lain yang memakai store yang sama. Mengembalikan false jika item tidak disimpan
+func (rc *ResponseCache) save(entry *utils.CacheEntry, epoch int64) bool {
+	if epoch < 0 {
+		return false
+	}
+	stored, err := rc.store.SetIfNotInvalidated(entry, epoch)
+	if err != nil {
+		log.Printf("Gagal menyimpan cache %s: %v", entry.Path, err)
+		return false
+	}
+	return stored
+}
+
+// CachePolicy mengatur cache untuk satu route
+type CachePolicy struct {
+	// Vary adalah header request yang membedakan isi respons, contoh "Authorization"
+	// untuk respons yang berbeda per user. Tanpa Vary, respons dibagi ke semua user
+	Vary []string
+	// StaleWhileRevalidate adalah lama respons kedaluwarsa masih dikirim
+	// sambil handler dijalankan untuk memperbarui cache
+	StaleWhileRevalidate time.Duration
+	// StaleIfError adalah lama respons kedaluwarsa dipakai jika handler gagal dengan status 5xx
+	StaleIfError time.Duration
+}
+
+// Middleware menyimpan respons GET ke cache dengan vary sebagai header pembeda
+func (rc *ResponseCache) Middleware(vary ...string) gin.HandlerFunc {
+	return rc.MiddlewareWithPolicy(CachePolicy{Vary: vary})
+}
+
+// MiddlewareWithPolicy menyimpan respons GET ke cache sesuai policy route
+// Dipasang per route setelah AuthMiddleware dan pengecekan permission agar cache hit tetap
+// melewati keduanya. Request bersamaan untuk key yang sama saat cache kosong hanya
+// menjalankan handler sekali, request lain menunggu hasilnya
+func (rc *ResponseCache) MiddlewareWithPolicy(policy CachePolicy) gin.HandlerFunc {
+	policy.Vary = normalizeVary(policy.Vary)
 
 	return func(c *gin.Context) {
 		// Hanya cache untuk GET requests
-		if c.Request.Method != "GET" {
+		if c.Request.Method != http.MethodGet {
 			c.Next()
 			return
 		}
@@ -88,70 +152,278 @@ func CacheMiddleware(expiration time.Duration) gin.HandlerFunc {
 			return
 		}
 
-		// Buat key cache dari URL dan header Authorization
-		auth := c.GetHeader("Authorization")
-		hashKey := sha256.Sum256([]byte(c.Request.URL.String() + auth))
-		key := hex.EncodeToString(hashKey[:])
-
-		// Cek apakah respons ada di cache
-		mutex.Lock()
-		item, exists := cache[key]
-		mutex.Unlock()
-
-		if exists && time.Now().Before(item.Expiration) {
-			// Respons ada di cache dan belum kedaluwarsa
-			c.Writer.Header().Set("Content-Type", "application/json")
-			c.Writer.Header().Set("X-Cache", "HIT")
-			c.Writer.Write(item.Content)
-			c.Abort()
+		// Klien yang mengirim no-store tidak ingin respons disimpan di mana pun
+		requestDirectives := parseCacheControl(c.GetHeader("Cache-Control"))
+		if _, ok := requestDirectives["no-store"]; ok {
+			c.Next()
+			return
+		}
+
+		key := cacheKey(c.Request, policy.Vary)
+
+		// no-cache atau max-age=0 dari klien memaksa respons baru, hasilnya tetap boleh disimpan
+		if wantsRevalidation(c.Request, requestDirectives) {
+			rc.record(c, rc.fill(c, key, policy, nil).served())
 			return
 		}
 
-		// Buat writer untuk menyimpan respons
-		writer := &responseWriter{body: &bytes.Buffer{}, ResponseWriter: c.Writer}
-		c.Writer = writer
+		// Store yang error dianggap cache miss
+		entry, exists, err := rc.store.Get(key)
+		if err != nil {
+			log.Printf("Gagal membaca cache: %v", err)
+		}
+		if !exists {
+			entry = nil
+		}
 
-		// Lanjutkan ke handler berikutnya
-		c.Next()
+		now := time.Now()
+		if entry != nil && entry.IsFresh(now) {
+			rc.record(c, "HIT")
+			writeCachedResponse(c, entry, "HIT")
+			c.Abort()
+			return
+		}
 
-		// Simpan respons di cache jika status code 200 OK
-		if c.Writer.Status() == http.StatusOK {
-			mutex.Lock()
-			cache[key] = cacheItem{
-				Content:    writer.body.Bytes(),
-				Expiration: time.Now().Add(expiration),
+		// Respons kedaluwarsa dalam jendela stale-while-revalidate dikirim lebih dulu,
+		// lalu satu request menjalankan handler untuk memperbarui cache
+		if entry != nil && now.Before(entry.FreshUntil.Add(policy.StaleWhileRevalidate)) {
+			rc.record(c, "STALE")
+			writeCachedResponse(c, entry, "STALE")
+			c.Writer.Flush()
+			if !rc.revalidate(c, key, policy) {
+				c.Abort()
 			}
-			mutex.Unlock()
-			c.Writer.Header().Set("X-Cache", "MISS")
+			return
 		}
+
+		rc.coalesce(c, key, policy, entry)
+	}
+}
+
+// fill menjalankan handler, menyimpan respons yang boleh di-cache lalu mengirimnya ke klien
+// stale dipakai jika handler gagal dalam jendela stale-if-error
+// Mengembalikan respons yang bisa dibagi ke request lain dengan key yang sama
+func (rc *ResponseCache) fill(c *gin.Context, key string, policy CachePolicy, stale *utils.CacheEntry) *sharedResponse {
+	epoch := rc.epoch()
+	writer := newResponseWriter(c.Writer, c.Writer.Header().Clone())
+	c.Writer = writer
+
+	// Lanjutkan ke handler berikutnya
+	c.Next()
+
+	c.Writer = writer.ResponseWriter
+	if writer.Status() >= http.StatusInternalServerError && stale != nil &&
+		time.Now().Before(stale.FreshUntil.Add(policy.StaleIfError)) {
+		log.Printf("Handler %s gagal dengan status %d, memakai cache lama", c.Request.URL.Path, writer.Status())
+		writeCachedResponse(c, stale, "STALE")
+		return &sharedResponse{entry: stale, state: "STALE"}
+	}
+	if writer.Status() != http.StatusOK {
+		writer.flush()
+		return nil
 	}
+
+	entry := rc.saveResponse(c, writer, key, policy, epoch)
+	header := writer.Header()
+	if entry != nil {
+		header.Set("X-Cache", "MISS")
+	}
+
+	// Klien boleh menyimpan respons tetapi harus memvalidasi ulang dengan ETag
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "private, no-cache")
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), header.Get("ETag")) {
+		writer.notModified()
+	} else {
+		writer.flush()
+	}
+
+	if entry == nil {
+		return nil
+	}
+	return &sharedResponse{entry: entry, state: "HIT"}
 }
 
-// responseWriter adalah wrapper untuk gin.ResponseWriter
+// saveResponse menambahkan ETag dan Vary ke respons 200 lalu menyimpannya jika
+// Cache-Control dan Vary dari handler mengizinkan. Mengembalikan nil jika tidak disimpan
+func (rc *ResponseCache) saveResponse(c *gin.Context, writer *responseWriter, key string, policy CachePolicy, epoch int64) *utils.CacheEntry {
+	header := writer.Header()
+	if header.Get("ETag") == "" {
+		header.Set("ETag", strongETag(writer.body.Bytes()))
+	}
+	for _, name := range policy.Vary {
+		header.Add("Vary", name)
+	}
+
+	ttl, ok := cacheTTL(header, policy.Vary, rc.expiration)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	entry := &utils.CacheEntry{
+		Key:        key,
+		Status:     writer.Status(),
+		Header:     storedHeader(header),
+		Content:    writer.body.Bytes(),
+		ETag:       header.Get("ETag"),
+		StoredAt:   now,
+		FreshUntil: now.Add(ttl),
+		// Entry disimpan lebih lama dari masa segarnya selama jendela stale masih berlaku
+		ExpiresAt: now.Add(ttl + max(policy.StaleWhileRevalidate, policy.StaleIfError)),
+		Path:      c.Request.URL.Path,
+		Tags:      c.GetStringSlice(utils.CacheTagsKey),
+	}
+	// Entry yang berbeda per token diberi tag user pemiliknya agar ikut terhapus per user
+	if user, ok := CurrentUser(c); ok && containsHeader(policy.Vary, "Authorization") {
+		entry.Tags = append(entry.Tags, utils.UserTag(user.ID))
+	}
+	if !rc.save(entry, epoch) {
+		return nil
+	}
+	return entry
+}
+
+// writeCachedResponse mengirim respons dari cache, atau 304 jika ETag klien masih sama
+// state dikirim di header X-Cache: HIT atau STALE
+func writeCachedResponse(c *gin.Context, entry *utils.CacheEntry, state string) {
+	header := c.Writer.Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set("X-Cache", state)
+	header.Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+
+	if etagMatches(c.GetHeader("If-None-Match"), entry.ETag) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	// Content-Length membuat klien menerima respons lengkap meski handler masih berjalan
+	header.Set("Content-Length", strconv.Itoa(len(entry.Content)))
+	c.Status(entry.Status)
+	c.Writer.Write(entry.Content)
+}
+
+// responseWriter menahan status, header dan body respons sampai middleware cache selesai
 type responseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	header http.Header
+	status int
+	body   *bytes.Buffer
+}
+
+// newResponseWriter membuat writer yang menahan respons, header awal diambil dari header
+func newResponseWriter(w gin.ResponseWriter, header http.Header) *responseWriter {
+	return &responseWriter{ResponseWriter: w, header: header, status: http.StatusOK, body: &bytes.Buffer{}}
+}
+
+// Header mengembalikan header yang ditahan
+func (w *responseWriter) Header() http.Header {
+	return w.header
 }
 
-// Write menyimpan respons di buffer dan menulis ke ResponseWriter asli
+// WriteHeader mencatat status tanpa mengirimnya
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow tidak mengirim apa pun, status dikirim saat flush
+func (w *responseWriter) WriteHeaderNow() {}
+
+// Flush tidak mengirim apa pun, body dikirim saat flush
+func (w *responseWriter) Flush() {}
+
+// Status mengembalikan status yang ditulis handler
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size mengembalikan ukuran body yang ditahan
+func (w *responseWriter) Size() int {
+	return w.body.Len()
+}
+
+// Write menyimpan respons di buffer
 func (w *responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
+	return w.body.Write(b)
 }
 
-// WriteString menyimpan respons string di buffer dan menulis ke ResponseWriter asli
+// WriteString menyimpan respons string di buffer
 func (w *responseWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
-	return w.ResponseWriter.WriteString(s)
+	return w.body.WriteString(s)
+}
+
+// copyHeader menyalin header yang ditahan ke ResponseWriter asli
+func (w *responseWriter) copyHeader() {
+	dst := w.ResponseWriter.Header()
+	for name, values := range w.header {
+		dst[name] = values
+	}
+}
+
+// flush mengirim status, header dan body yang ditahan ke ResponseWriter asli
+func (w *responseWriter) flush() {
+	w.copyHeader()
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// notModified mengirim 304 tanpa body
+func (w *responseWriter) notModified() {
+	w.copyHeader()
+	w.ResponseWriter.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+	w.ResponseWriter.WriteHeaderNow()
 }
 
-// ClearCache untuk menghapus semua cache
+// ClearCache untuk menghapus cache: semua item, item dengan prefix path tertentu,
+// atau item yang berisi data satu user. Mengembalikan jumlah item yang dihapus
 // @exclude
-func ClearCache() gin.HandlerFunc {
+func ClearCache(cache *ResponseCache) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Body opsional: {"prefix": "/posts"} atau {"user_id": 42}
+		var input struct {
+			Prefix string `json:"prefix"`
+			UserID uint   `json:"user_id"`
+		}
+		_ = c.ShouldBindJSON(&input)
+
+		if input.Prefix != "" && input.UserID != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "Gunakan prefix atau user_id, tidak keduanya",
+			})
+			return
+		}
+
+		var evicted int
+		var err error
+		switch {
+		case input.Prefix != "":
+			evicted, err = cache.ClearPrefix(input.Prefix)
+		case input.UserID != 0:
+			evicted, err = cache.ClearUser(input.UserID)
+		default:
+			evicted, err = cache.Clear()
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Gagal menghapus cache",
+			})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"status":  http.StatusOK,
 			"message": "Cache berhasil dihapus",
+			"evicted": evicted,
 		})
 	}
-}
\ No newline at end of file
+}
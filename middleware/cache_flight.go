@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"final/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sharedResponse adalah hasil satu handler yang dibagi ke request lain dengan key yang sama
+type sharedResponse struct {
+	entry *utils.CacheEntry
+	// state dikirim di header X-Cache: HIT atau STALE
+	state string
+}
+
+// coalesce menjalankan handler sekali untuk semua request bersamaan dengan key yang sama
+// Request yang menunggu memakai respons yang disimpan oleh request pertama. Jika respons
+// tersebut tidak boleh di-cache, masing-masing request menjalankan handler sendiri
+func (rc *ResponseCache) coalesce(c *gin.Context, key string, policy CachePolicy, stale *utils.CacheEntry) {
+	leader := false
+	result, _, _ := rc.flights.Do(key, func() (interface{}, error) {
+		leader = true
+		return rc.fill(c, key, policy, stale), nil
+	})
+	shared := result.(*sharedResponse)
+	if leader {
+		rc.record(c, shared.served())
+		return
+	}
+
+	rc.mutex.Lock()
+	rc.coalesced++
+	rc.mutex.Unlock()
+
+	if shared != nil {
+		rc.record(c, shared.state)
+		writeCachedResponse(c, shared.entry, shared.state)
+		c.Abort()
+		return
+	}
+	rc.record(c, rc.fill(c, key, policy, stale).served())
+}
+
+// served mengembalikan asal respons untuk statistik: STALE jika memakai cache lama, selain itu MISS
+func (r *sharedResponse) served() string {
+	if r != nil && r.state == "STALE" {
+		return "STALE"
+	}
+	return "MISS"
+}
+
+// revalidate menjalankan handler setelah respons lama terkirim untuk memperbarui cache
+// Hanya satu request per key yang memperbarui, mengembalikan false jika request lain sudah melakukannya
+func (rc *ResponseCache) revalidate(c *gin.Context, key string, policy CachePolicy) bool {
+	rc.mutex.Lock()
+	if rc.revalidating[key] {
+		rc.mutex.Unlock()
+		return false
+	}
+	rc.revalidating[key] = true
+	rc.mutex.Unlock()
+
+	defer func() {
+		rc.mutex.Lock()
+		delete(rc.revalidating, key)
+		rc.mutex.Unlock()
+	}()
+
+	// Respons handler tidak dikirim karena klien sudah menerima respons lama
+	epoch := rc.epoch()
+	original := c.Writer
+	writer := newResponseWriter(original, http.Header{})
+	c.Writer = writer
+	c.Next()
+	c.Writer = original
+
+	if writer.Status() == http.StatusOK {
+		rc.saveResponse(c, writer, key, policy, epoch)
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header yang hanya berlaku untuk satu respons dan tidak ikut disimpan di cache
+var uncachedHeaders = []string{"Age", "Connection", "Date", "Set-Cookie", "X-Cache", "RateLimit-Limit", "RateLimit-Remaining", "Retry-After"}
+
+// parseCacheControl mengurai header Cache-Control menjadi map directive ke nilai
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// wantsRevalidation mengecek apakah klien meminta respons baru, bukan dari cache
+func wantsRevalidation(r *http.Request, directives map[string]string) bool {
+	if _, ok := directives["no-cache"]; ok {
+		return true
+	}
+	if maxAge, ok := directives["max-age"]; ok && maxAge == "0" {
+		return true
+	}
+	// Pragma dipakai klien HTTP/1.0 jika Cache-Control tidak ada
+	return len(directives) == 0 && strings.EqualFold(r.Header.Get("Pragma"), "no-cache")
+}
+
+// cacheTTL menentukan apakah respons boleh disimpan di cache server dan berapa lama
+// mengikuti Cache-Control dan Vary dari handler
+func cacheTTL(header http.Header, vary []string, fallback time.Duration) (time.Duration, bool) {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return 0, false
+	}
+	// Respons private hanya boleh disimpan jika entry cache-nya terpisah per user
+	if _, ok := directives["private"]; ok && !containsHeader(vary, "Authorization") {
+		return 0, false
+	}
+
+	// Vary dari handler yang tidak dideklarasikan di route membuat key cache tidak lengkap
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" || (name != "" && !containsHeader(vary, name)) {
+				return 0, false
+			}
+		}
+	}
+
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[name]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return fallback, true
+}
+
+// normalizeVary menyeragamkan penulisan nama header dan mengurutkannya
+func normalizeVary(vary []string) []string {
+	normalized := make([]string, 0, len(vary))
+	for _, name := range vary {
+		normalized = append(normalized, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// containsHeader mengecek apakah nama header ada di daftar (tidak peka huruf besar/kecil)
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey membuat key cache dari URL dan nilai header yang ada di vary
+func cacheKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(r.URL.String())
+	for _, name := range vary {
+		b.WriteString("\n" + name + ":" + strings.Join(r.Header.Values(name), ","))
+	}
+	hash := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+// strongETag membuat ETag kuat dari isi respons
+func strongETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// etagMatches mengecek If-None-Match terhadap ETag respons (perbandingan lemah sesuai RFC 9110)
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// storedHeader menyalin header respons tanpa header yang hanya berlaku sekali
+func storedHeader(header http.Header) http.Header {
+	stored := header.Clone()
+	for _, name := range uncachedHeaders {
+		stored.Del(name)
+	}
+	return stored
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"final/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheRouteStats adalah jumlah hit, miss dan respons lama untuk satu route
+type CacheRouteStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Stale adalah respons kedaluwarsa yang dikirim karena stale-while-revalidate atau stale-if-error
+	Stale    int64   `json:"stale"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// CacheStats adalah statistik ResponseCache sejak server berjalan
+type CacheStats struct {
+	CacheRouteStats
+	// Coalesced adalah request yang menunggu hasil request lain dengan key yang sama
+	Coalesced int64 `json:"coalesced"`
+	// Invalidations adalah entry yang dihapus karena data berubah atau dihapus admin
+	Invalidations int64                      `json:"invalidations"`
+	Store         utils.CacheStoreStats      `json:"store"`
+	Routes        map[string]CacheRouteStats `json:"routes"`
+}
+
+// record mencatat hasil satu request: HIT, STALE atau MISS
+// Route dicatat dalam bentuk template, contoh "/posts/:id"
+func (rc *ResponseCache) record(c *gin.Context, state string) {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	stats, ok := rc.routes[route]
+	if !ok {
+		stats = &CacheRouteStats{}
+		rc.routes[route] = stats
+	}
+	switch state {
+	case "HIT":
+		stats.Hits++
+	case "STALE":
+		stats.Stale++
+	default:
+		stats.Misses++
+	}
+}
+
+// countInvalidations menambahkan jumlah entry yang dihapus ke statistik
+func (rc *ResponseCache) countInvalidations(count int, err error) (int, error) {
+	rc.mutex.Lock()
+	rc.invalidations += int64(count)
+	rc.mutex.Unlock()
+	return count, err
+}
+
+// Stats mengembalikan statistik cache beserta kondisi store
+func (rc *ResponseCache) Stats() (CacheStats, error) {
+	store, err := rc.store.Stats()
+	if err != nil {
+		return CacheStats{}, err
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	stats := CacheStats{
+		Coalesced:     rc.coalesced,
+		Invalidations: rc.invalidations,
+		Store:         store,
+		Routes:        make(map[string]CacheRouteStats, len(rc.routes)),
+	}
+	for route, routeStats := range rc.routes {
+		result := *routeStats
+		result.HitRatio = hitRatio(result)
+		stats.Routes[route] = result
+
+		stats.Hits += result.Hits
+		stats.Misses += result.Misses
+		stats.Stale += result.Stale
+	}
+	stats.HitRatio = hitRatio(stats.CacheRouteStats)
+	return stats, nil
+}
+
+// hitRatio menghitung bagian request yang dijawab dari cache, termasuk respons lama
+func hitRatio(stats CacheRouteStats) float64 {
+	total := stats.Hits + stats.Misses + stats.Stale
+	if total == 0 {
+		return 0
+	}
+	return float64(stats.Hits+stats.Stale) / float64(total)
+}
+
+// cacheEntryInfo adalah metadata entry cache tanpa isi respons
+type cacheEntryInfo struct {
+	Key        string      `json:"key"`
+	Path       string      `json:"path"`
+	Status     int         `json:"status"`
+	ETag       string      `json:"etag"`
+	Tags       []string    `json:"tags"`
+	Header     http.Header `json:"header,omitempty"`
+	Size       int64       `json:"size"`
+	Fresh      bool        `json:"fresh"`
+	StoredAt   time.Time   `json:"stored_at"`
+	FreshUntil time.Time   `json:"fresh_until"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+}
+
+// newCacheEntryInfo membuat metadata entry, header hanya disertakan jika withHeader
+func newCacheEntryInfo(entry *utils.CacheEntry, withHeader bool) cacheEntryInfo {
+	info := cacheEntryInfo{
+		Key:        entry.Key,
+		Path:       entry.Path,
+		Status:     entry.Status,
+		ETag:       entry.ETag,
+		Tags:       entry.Tags,
+		Size:       entry.Size(),
+		Fresh:      entry.IsFresh(time.Now()),
+		StoredAt:   entry.StoredAt,
+		FreshUntil: entry.FreshUntil,
+		ExpiresAt:  entry.ExpiresAt,
+	}
+	if withHeader {
+		info.Header = entry.Header
+	}
+	return info
+}
+
+// GetCacheStats untuk melihat hit, miss, eviction, hit ratio per route dan pemakaian memori cache
+// @exclude
+func GetCacheStats(cache *ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := cache.Stats()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Gagal membaca statistik cache",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// ListCacheEntries untuk melihat metadata entry cache yang path-nya diawali ?prefix=
+// Jumlah entry dibatasi ?limit= (default 100, maksimal 1000). Seperti Peek, List tidak
+// mengubah urutan eviction
+// @exclude
+func ListCacheEntries(cache *ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  http.StatusBadRequest,
+				"message": "limit harus antara 1 dan 1000",
+			})
+			return
+		}
+
+		entries, err := cache.store.List(c.Query("prefix"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Gagal membaca cache",
+			})
+			return
+		}
+
+		infos := make([]cacheEntryInfo, 0, len(entries))
+		for _, entry := range entries {
+			infos = append(infos, newCacheEntryInfo(entry, false))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"count":   len(infos),
+			"entries": infos,
+		})
+	}
+}
+
+// GetCacheEntry untuk melihat metadata satu entry cache beserta header yang disimpan
+// Entry dibaca dengan Peek agar pemeriksaan admin tidak mengubah urutan eviction
+// @exclude
+func GetCacheEntry(cache *ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry, exists, err := cache.store.Peek(c.Param("key"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  http.StatusInternalServerError,
+				"message": "Gagal membaca cache",
+			})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  http.StatusNotFound,
+				"message": "Entry cache tidak ditemukan",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, newCacheEntryInfo(entry, true))
+	}
+}
@@ -0,0 +1,492 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClearCache tests clearing everything, by path prefix and by user
+func TestClearCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	authenticated := r.Group("/", func(c *gin.Context) {
+		SetCurrentUser(c, testUser(c.GetHeader("X-User"), utils.RoleUser))
+		c.Next()
+	}, cache.Middleware("Authorization"))
+	authenticated.GET("/posts", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"posts": []string{}}) })
+	authenticated.GET("/users", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"users": []string{}}) })
+	r.POST("/admin/cache/clear", ClearCache(cache))
+
+	get := func(path, user string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("Authorization", "Bearer "+user)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	clear := func(body map[string]interface{}) int {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, "/admin/cache/clear", bytes.NewBuffer(payload))
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var result struct {
+			Evicted int `json:"evicted"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &result)
+		return result.Evicted
+	}
+	fill := func() {
+		for _, user := range []string{"alice", "bob"} {
+			get("/posts", user)
+			get("/users", user)
+		}
+	}
+
+	fill()
+	assert.Equal(t, "HIT", get("/posts", "alice").Header().Get("X-Cache"))
+	assert.Equal(t, 4, cacheLen(t, cache))
+
+	assert.Equal(t, 2, clear(map[string]interface{}{"prefix": "/posts"}))
+	assert.Equal(t, "MISS", get("/posts", "alice").Header().Get("X-Cache"))
+
+	// alice: /posts (baru diisi ulang) dan /users, bob: /users
+	assert.Equal(t, 2, clear(map[string]interface{}{"user_id": testUserIDs["alice"]}))
+	assert.Equal(t, 1, cacheLen(t, cache))
+
+	fill()
+	assert.Equal(t, 4, clear(nil))
+	assert.Equal(t, 0, cacheLen(t, cache))
+}
+
+// TestClearCacheUserSharedRoutes tests clearing a user on routes configured like routes.SetupRouter:
+// shared entries without Vary that are tagged with the users they contain
+func TestClearCacheUserSharedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	authenticated := r.Group("/", func(c *gin.Context) {
+		SetCurrentUser(c, testUser("alice", utils.RoleAdmin))
+		c.Next()
+	})
+	// Sama seperti controllers.tagUsers dan tagPosts
+	authenticated.GET("/users/:id", cache.Middleware(), func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{"user:" + c.Param("id")})
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	authenticated.GET("/posts", cache.MiddlewareWithPolicy(CachePolicy{StaleWhileRevalidate: 30 * time.Second}), func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{utils.PostTag(7), utils.UserTag(2)})
+		c.JSON(http.StatusOK, gin.H{"posts": []int{7}})
+	})
+	r.POST("/admin/cache/clear", ClearCache(cache))
+
+	for _, path := range []string{"/users/1", "/users/2", "/posts"} {
+		serveCached(r, path, nil)
+	}
+	assert.Equal(t, 3, cacheLen(t, cache))
+
+	payload, _ := json.Marshal(map[string]interface{}{"user_id": 2})
+	req, _ := http.NewRequest(http.MethodPost, "/admin/cache/clear", bytes.NewBuffer(payload))
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"status":200,"message":"Cache berhasil dihapus","evicted":2}`, resp.Body.String())
+
+	assert.Equal(t, "HIT", serveCached(r, "/users/1", nil).Header().Get("X-Cache"))
+	assert.Equal(t, "MISS", serveCached(r, "/users/2", nil).Header().Get("X-Cache"))
+	assert.Equal(t, "MISS", serveCached(r, "/posts", nil).Header().Get("X-Cache"))
+}
+
+// TestInvalidateTags tests that only entries carrying an invalidated tag are evicted
+func TestInvalidateTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.Use(cache.Middleware())
+	r.GET("/posts", func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{utils.TagPostsList, utils.PostTag(1), utils.PostTag(2)})
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.GET("/posts/:id", func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{"post:" + c.Param("id")})
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	get := func(path string) string {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp.Header().Get("X-Cache")
+	}
+
+	get("/posts")
+	get("/posts/1")
+	get("/posts/2")
+	assert.Equal(t, 3, cacheLen(t, cache))
+
+	// Post 1 berubah: detail post 1 dan daftar post dihapus, post 2 tetap
+	assert.Equal(t, 2, cache.InvalidateTags(utils.PostTag(1)))
+	assert.Equal(t, "HIT", get("/posts/2"))
+	assert.Equal(t, 1, cacheLen(t, cache))
+
+	assert.Equal(t, 0, cache.InvalidateTags("post:999"))
+}
+
+// TestInvalidateDuringRequest tests that a response built before an invalidation is not stored
+func TestInvalidateDuringRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.Use(cache.Middleware())
+	r.GET("/posts/1", func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{utils.PostTag(1)})
+		// Post diubah oleh request lain saat respons lama sedang dibuat
+		cache.InvalidateTags(utils.PostTag(1))
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 0, cacheLen(t, cache))
+}
+
+// TestInvalidateOnOtherReplica tests that an invalidation by another instance sharing the store
+// also stops a slow fill from saving a stale response
+func TestInvalidateOnOtherReplica(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := utils.NewMemoryCacheStore(100, 1<<20)
+	filling := NewResponseCache(store, time.Minute)
+	other := NewResponseCache(store, time.Minute)
+
+	r := gin.New()
+	r.Use(filling.Middleware())
+	r.GET("/posts/1", func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{utils.PostTag(1)})
+		// Post diubah lewat replika lain saat respons lama sedang dibuat
+		other.InvalidateTags(utils.PostTag(1))
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 0, cacheLen(t, filling))
+}
+
+// TestCacheStoresFullResponse tests that a hit replays status, headers and body with a strong ETag
+func TestCacheStoresFullResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.GET("/feed", cache.Middleware(), func(c *gin.Context) {
+		c.Header("X-Total-Count", "3")
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte("feed"))
+	})
+
+	miss := serveCached(r, "/feed", nil)
+	assert.Equal(t, http.StatusOK, miss.Code)
+	assert.Equal(t, "MISS", miss.Header().Get("X-Cache"))
+	etag := miss.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+"$`, etag)
+	assert.Equal(t, "private, no-cache", miss.Header().Get("Cache-Control"))
+
+	hit := serveCached(r, "/feed", nil)
+	assert.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, "HIT", hit.Header().Get("X-Cache"))
+	assert.Equal(t, "text/plain; charset=utf-8", hit.Header().Get("Content-Type"))
+	assert.Equal(t, "3", hit.Header().Get("X-Total-Count"))
+	assert.Equal(t, etag, hit.Header().Get("ETag"))
+	assert.Equal(t, "feed", hit.Body.String())
+}
+
+// TestCacheConditionalRequest tests that a matching If-None-Match is answered with 304
+func TestCacheConditionalRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.GET("/posts", cache.Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"posts": []string{"a"}})
+	})
+
+	// Klien yang belum punya cache di server tetap mendapat 304 jika ETag-nya sama
+	etag := strongETag([]byte(`{"posts":["a"]}`))
+	miss := serveCached(r, "/posts", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, miss.Code)
+	assert.Empty(t, miss.Body.String())
+
+	hit := serveCached(r, "/posts", map[string]string{"If-None-Match": `"other", W/` + etag})
+	assert.Equal(t, http.StatusNotModified, hit.Code)
+	assert.Equal(t, "HIT", hit.Header().Get("X-Cache"))
+	assert.Empty(t, hit.Body.String())
+
+	changed := serveCached(r, "/posts", map[string]string{"If-None-Match": `"other"`})
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.JSONEq(t, `{"posts":["a"]}`, changed.Body.String())
+}
+
+// TestCacheControl tests request and response Cache-Control directives
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	calls := 0
+	r := gin.New()
+	r.GET("/posts", cache.Middleware(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	r.GET("/me", cache.Middleware(), func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.GET("/private", cache.Middleware(), func(c *gin.Context) {
+		c.Header("Cache-Control", "private, max-age=60")
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	serveCached(r, "/posts", nil)
+	assert.Equal(t, "HIT", serveCached(r, "/posts", nil).Header().Get("X-Cache"))
+
+	// no-cache dari klien melewati cache tetapi menyimpan respons terbaru
+	fresh := serveCached(r, "/posts", map[string]string{"Cache-Control": "no-cache"})
+	assert.Equal(t, "MISS", fresh.Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+	assert.JSONEq(t, `{"calls":2}`, serveCached(r, "/posts", nil).Body.String())
+
+	// no-store dari klien tidak membaca maupun menyimpan cache
+	assert.Empty(t, serveCached(r, "/posts", map[string]string{"Cache-Control": "no-store"}).Header().Get("X-Cache"))
+	assert.Equal(t, 3, calls)
+
+	serveCached(r, "/me", nil)
+	serveCached(r, "/private", nil)
+	assert.Equal(t, 1, cacheLen(t, cache))
+	assert.Equal(t, "no-store", serveCached(r, "/me", nil).Header().Get("Cache-Control"))
+}
+
+// TestCacheVary tests that entries are keyed on the declared Vary headers only
+func TestCacheVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.GET("/shared", cache.Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	r.GET("/me/feed", cache.Middleware("Authorization"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"token": c.GetHeader("Authorization")})
+	})
+	r.GET("/lang", cache.Middleware(), func(c *gin.Context) {
+		c.Header("Vary", "Accept-Language")
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	serveCached(r, "/shared", map[string]string{"Authorization": "Bearer a"})
+	assert.Equal(t, "HIT", serveCached(r, "/shared", map[string]string{"Authorization": "Bearer b"}).Header().Get("X-Cache"))
+
+	serveCached(r, "/me/feed", map[string]string{"Authorization": "Bearer a"})
+	other := serveCached(r, "/me/feed", map[string]string{"Authorization": "Bearer b"})
+	assert.Equal(t, "MISS", other.Header().Get("X-Cache"))
+	assert.Equal(t, "Authorization", other.Header().Get("Vary"))
+	assert.JSONEq(t, `{"token":"Bearer b"}`, other.Body.String())
+	assert.Equal(t, "HIT", serveCached(r, "/me/feed", map[string]string{"Authorization": "Bearer a"}).Header().Get("X-Cache"))
+
+	// Vary yang tidak dideklarasikan di route tidak disimpan
+	serveCached(r, "/lang", nil)
+	assert.Empty(t, serveCached(r, "/lang", nil).Header().Get("X-Cache"))
+	assert.Equal(t, 3, cacheLen(t, cache))
+}
+
+// TestCacheCoalescesConcurrentMisses tests that concurrent misses for one key run the handler once
+func TestCacheCoalescesConcurrentMisses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	var calls int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.GET("/posts", cache.Middleware(), func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(entered)
+		}
+		<-release
+		c.JSON(http.StatusOK, gin.H{"posts": []string{"a"}})
+	})
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 5)
+	serve := func(i int) {
+		defer wg.Done()
+		responses[i] = serveCached(r, "/posts", nil)
+	}
+	wg.Add(1)
+	go serve(0)
+	<-entered
+	for i := 1; i < len(responses); i++ {
+		wg.Add(1)
+		go serve(i)
+	}
+	// Beri waktu request lain untuk ikut menunggu hasil request pertama
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, resp := range responses {
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"posts":["a"]}`, resp.Body.String())
+	}
+}
+
+// TestCacheStaleWhileRevalidate tests that an expired entry is served once while the handler refreshes it
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), 20*time.Millisecond)
+
+	calls := 0
+	r := gin.New()
+	r.GET("/posts", cache.MiddlewareWithPolicy(CachePolicy{StaleWhileRevalidate: time.Minute}), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	serveCached(r, "/posts", nil)
+	time.Sleep(30 * time.Millisecond)
+
+	stale := serveCached(r, "/posts", nil)
+	assert.Equal(t, "STALE", stale.Header().Get("X-Cache"))
+	assert.JSONEq(t, `{"calls":1}`, stale.Body.String())
+	assert.Equal(t, 2, calls)
+
+	fresh := serveCached(r, "/posts", nil)
+	assert.Equal(t, "HIT", fresh.Header().Get("X-Cache"))
+	assert.JSONEq(t, `{"calls":2}`, fresh.Body.String())
+}
+
+// TestCacheStaleIfError tests that an expired entry replaces a 5xx response within the stale-if-error window
+func TestCacheStaleIfError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), 20*time.Millisecond)
+
+	failing := false
+	r := gin.New()
+	r.GET("/posts", cache.MiddlewareWithPolicy(CachePolicy{StaleIfError: time.Minute}), func(c *gin.Context) {
+		if failing {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"posts": []string{"a"}})
+	})
+	r.GET("/users", cache.Middleware(), func(c *gin.Context) {
+		if failing {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	serveCached(r, "/posts", nil)
+	serveCached(r, "/users", nil)
+	time.Sleep(30 * time.Millisecond)
+	failing = true
+
+	resp := serveCached(r, "/posts", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "STALE", resp.Header().Get("X-Cache"))
+	assert.JSONEq(t, `{"posts":["a"]}`, resp.Body.String())
+
+	// Route tanpa stale-if-error mengembalikan error apa adanya
+	assert.Equal(t, http.StatusInternalServerError, serveCached(r, "/users", nil).Code)
+}
+
+// TestCacheStats tests hit and miss counters per route and the admin inspection endpoints
+func TestCacheStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewResponseCache(utils.NewMemoryCacheStore(100, 1<<20), time.Minute)
+
+	r := gin.New()
+	r.GET("/posts/:id", cache.Middleware(), func(c *gin.Context) {
+		c.Set(utils.CacheTagsKey, []string{utils.PostTag(1)})
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	r.GET("/users", cache.Middleware(), func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	r.GET("/admin/cache/stats", GetCacheStats(cache))
+	r.GET("/admin/cache/entries", ListCacheEntries(cache))
+	r.GET("/admin/cache/entries/:key", GetCacheEntry(cache))
+
+	serveCached(r, "/posts/1", nil)
+	serveCached(r, "/posts/1", nil)
+	serveCached(r, "/posts/1", nil)
+	serveCached(r, "/posts/2", nil)
+	serveCached(r, "/users", nil)
+	cache.InvalidateTags(utils.PostTag(1))
+
+	var stats CacheStats
+	resp := serveCached(r, "/admin/cache/stats", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.InDelta(t, 0.4, stats.HitRatio, 0.001)
+	assert.Equal(t, int64(2), stats.Invalidations)
+	assert.Equal(t, CacheRouteStats{Hits: 2, Misses: 2, HitRatio: 0.5}, stats.Routes["/posts/:id"])
+	assert.Equal(t, 1, stats.Store.Entries)
+	assert.Equal(t, "memory", stats.Store.Backend)
+
+	var list struct {
+		Count   int              `json:"count"`
+		Entries []cacheEntryInfo `json:"entries"`
+	}
+	serveCached(r, "/posts/3", nil)
+	resp = serveCached(r, "/admin/cache/entries?prefix=/posts", nil)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Count)
+	assert.Equal(t, "/posts/3", list.Entries[0].Path)
+	assert.True(t, list.Entries[0].Fresh)
+	assert.Equal(t, http.StatusBadRequest, serveCached(r, "/admin/cache/entries?limit=0", nil).Code)
+
+	var info cacheEntryInfo
+	resp = serveCached(r, "/admin/cache/entries/"+list.Entries[0].Key, nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	assert.Equal(t, []string{utils.PostTag(1)}, info.Tags)
+	assert.Equal(t, "application/json; charset=utf-8", info.Header.Get("Content-Type"))
+	assert.Equal(t, http.StatusNotFound, serveCached(r, "/admin/cache/entries/missing", nil).Code)
+}
+
+// serveCached mengirim GET dengan header tambahan
+func serveCached(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	return resp
+}
+
+// cacheLen mengembalikan jumlah item di cache
+func cacheLen(t *testing.T, cache *ResponseCache) int {
+	t.Helper()
+	count, err := cache.Len()
+	assert.Nil(t, err)
+	return count
+}
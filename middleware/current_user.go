@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"final/config"
+	"final/models"
+	"final/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserKey adalah kunci context untuk *utils.CurrentUser
+const currentUserKey = "current_user"
+
+// loadUser memuat user berdasarkan ID dari claim sub, diganti di test tanpa database
+var loadUser = func(id uint) (models.User, error) {
+	var user models.User
+	err := config.DB.First(&user, id).Error
+	return user, err
+}
+
+// SetCurrentUser menyimpan user yang terautentikasi ke context
+// Kunci lama (username, role, claims, api_key_id, scopes) ikut diisi untuk middleware
+// yang hanya membutuhkan nilai tersebut, role selalu diambil dari data user saat ini
+func SetCurrentUser(c *gin.Context, user *utils.CurrentUser) {
+	c.Set(currentUserKey, user)
+	c.Set("username", user.Username)
+	c.Set("role", user.Role)
+	if user.Claims != nil {
+		c.Set("claims", user.Claims)
+	}
+	if user.IsAPIKey() {
+		c.Set("api_key_id", user.APIKeyID)
+		c.Set("scopes", user.Scopes)
+	}
+}
+
+// CurrentUser mengembalikan user yang dimuat oleh AuthMiddleware
+func CurrentUser(c *gin.Context) (*utils.CurrentUser, bool) {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := value.(*utils.CurrentUser)
+	return user, ok && user != nil
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"final/config"
+	"final/models"
+	"final/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// saveImpersonationAudit menyimpan atau memperbarui catatan audit, diganti di test tanpa database
+var saveImpersonationAudit = func(entry *models.ImpersonationAudit) error {
+	return config.DB.Save(entry).Error
+}
+
+// auditImpersonation mencatat request yang dilakukan admin atas nama user
+// Catatan dibuat sebelum handler berjalan sehingga request yang gagal dicatat tidak diteruskan,
+// lalu status response ditambahkan setelah handler selesai
+func auditImpersonation(c *gin.Context, user *utils.CurrentUser) {
+	tokenID, _ := (*user.Claims)["jti"].(string)
+	path := c.Request.URL.RequestURI()
+	if len(path) > 255 {
+		path = path[:255]
+	}
+
+	entry := &models.ImpersonationAudit{
+		AdminID: user.Impersonator.ID,
+		UserID:  user.ID,
+		TokenID: tokenID,
+		Action:  utils.ImpersonationRequest,
+		Method:  c.Request.Method,
+		Path:    path,
+		IP:      c.ClientIP(),
+	}
+	if err := saveImpersonationAudit(entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  http.StatusInternalServerError,
+			"message": "Gagal mencatat audit impersonasi",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+
+	entry.Status = c.Writer.Status()
+	if err := saveImpersonationAudit(entry); err != nil {
+		log.Printf("Gagal menyimpan status audit impersonasi %d: %v", entry.ID, err)
+	}
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"final/models"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubImpersonationAudits menyimpan catatan audit di memori selama test
+func stubImpersonationAudits(t *testing.T) *[]models.ImpersonationAudit {
+	original := saveImpersonationAudit
+	t.Cleanup(func() { saveImpersonationAudit = original })
+
+	var entries []models.ImpersonationAudit
+	saveImpersonationAudit = func(entry *models.ImpersonationAudit) error {
+		if entry.ID == 0 {
+			entry.ID = uint(len(entries) + 1)
+			entries = append(entries, *entry)
+			return nil
+		}
+		entries[entry.ID-1] = *entry
+		return nil
+	}
+	return &entries
+}
+
+// TestImpersonationToken tests that an impersonation token acts as the target, is audited and cannot manage the account
+func TestImpersonationToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.SetTokenCache(utils.NewTokenCache(100))
+	defer utils.SetTokenCache(utils.NewTokenCache(100))
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+
+	admin := testUser("alice", utils.RoleAdmin).User
+	target := testUser("bob", utils.RoleUser).User
+	stubUsers(t, admin, target)
+	audits := stubImpersonationAudits(t)
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) {
+		user, _ := CurrentUser(c)
+		c.JSON(http.StatusOK, gin.H{"username": user.Username, "impersonator": user.Impersonator.Username})
+	})
+	r.DELETE("/users/:id", AuthMiddleware(), RequireSession(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	td, err := utils.GenerateImpersonationToken(admin, target)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/me?tab=posts", nil)
+	req.Header.Set("Authorization", "Bearer "+td.AccessToken)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"username":"bob","impersonator":"alice"}`, w.Body.String())
+
+	// Aksi sensitif ditolak, tetapi percobaannya tetap tercatat
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodDelete, "/users/2", nil)
+	req.Header.Set("Authorization", "Bearer "+td.AccessToken)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	if assert.Len(t, *audits, 2) {
+		first, second := (*audits)[0], (*audits)[1]
+		assert.Equal(t, admin.ID, first.AdminID)
+		assert.Equal(t, target.ID, first.UserID)
+		assert.Equal(t, td.AccessUUID, first.TokenID)
+		assert.Equal(t, utils.ImpersonationRequest, first.Action)
+		assert.Equal(t, "/me?tab=posts", first.Path)
+		assert.Equal(t, http.StatusOK, first.Status)
+		assert.Equal(t, http.MethodDelete, second.Method)
+		assert.Equal(t, http.StatusForbidden, second.Status)
+	}
+}
+
+// TestImpersonationTokenRevoked tests that the token stops working when the admin is revoked or demoted
+func TestImpersonationTokenRevoked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.SetTokenCache(utils.NewTokenCache(100))
+	defer utils.SetTokenCache(utils.NewTokenCache(100))
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+	stubImpersonationAudits(t)
+
+	admin := testUser("alice", utils.RoleAdmin).User
+	target := testUser("bob", utils.RoleUser).User
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	td, err := utils.GenerateImpersonationToken(admin, target)
+	assert.Nil(t, err)
+
+	request := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+td.AccessToken)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	stubUsers(t, admin, target)
+	assert.Equal(t, http.StatusOK, request())
+
+	revoked := admin
+	revoked.TokenVersion++
+	stubUsers(t, revoked, target)
+	assert.Equal(t, http.StatusUnauthorized, request())
+
+	demoted := admin
+	demoted.Role = utils.RoleUser
+	stubUsers(t, demoted, target)
+	assert.Equal(t, http.StatusUnauthorized, request())
+
+	stubUsers(t, target)
+	assert.Equal(t, http.StatusUnauthorized, request())
+}
+
+// TestImpersonationAuditFailure tests that requests are refused when they cannot be audited
+func TestImpersonationAuditFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.SetTokenCache(utils.NewTokenCache(100))
+	defer utils.SetTokenCache(utils.NewTokenCache(100))
+	utils.SetRevocationStore(utils.NewMemoryRevocationStore())
+
+	admin := testUser("alice", utils.RoleAdmin).User
+	target := testUser("bob", utils.RoleUser).User
+	stubUsers(t, admin, target)
+
+	original := saveImpersonationAudit
+	t.Cleanup(func() { saveImpersonationAudit = original })
+	saveImpersonationAudit = func(entry *models.ImpersonationAudit) error { return errors.New("database down") }
+
+	called := false
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { called = true })
+
+	td, err := utils.GenerateImpersonationToken(admin, target)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+td.AccessToken)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, called)
+}
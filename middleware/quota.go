@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"final/config"
+	"final/utils"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireDailyQuota membatasi operasi mahal per user per hari sesuai role (utils.QuotaFor)
+// Kuota dipakai sebelum handler berjalan dan dikembalikan jika handler gagal (status >= 400)
+// sehingga request yang ditolak validasi tidak menghabiskan kuota. Dipasang setelah AuthMiddleware
+func RequireDailyQuota(op utils.QuotaOperation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  http.StatusUnauthorized,
+				"message": "User tidak terautentikasi",
+			})
+			c.Abort()
+			return
+		}
+
+		role := user.Role
+		limit := utils.QuotaFor(role).Daily[op]
+
+		used, ok, err := utils.ReserveDailyQuota(config.DB, user.ID, op, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check quota"})
+			c.Abort()
+			return
+		}
+
+		if !ok {
+			now := time.Now()
+			resetAt := utils.QuotaResetAt(now)
+			retryAfter := int(math.Ceil(resetAt.Sub(now).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Daily quota exceeded",
+				"quota": gin.H{
+					"operation": op,
+					"tier":      role,
+					"limit":     limit,
+					"used":      used,
+					"reset_at":  resetAt,
+				},
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		// Operasi gagal, kuota dikembalikan
+		if c.Writer.Status() >= http.StatusBadRequest {
+			if err := utils.ReleaseDailyQuota(config.DB, user.ID, op); err != nil {
+				log.Printf("Gagal mengembalikan kuota %s user %d: %v", op, user.ID, err)
+			}
+		}
+	}
+}
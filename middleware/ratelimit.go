@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"final/config"
+	"final/utils"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc menentukan kunci bucket rate limit untuk sebuah request
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP membatasi per alamat IP klien
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser membatasi per user ID dari AuthMiddleware sehingga mengganti username
+// tidak mengosongkan bucket, request tanpa login dibatasi per IP
+func KeyByUser(c *gin.Context) string {
+	if user, ok := CurrentUser(c); ok {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey membatasi per API key untuk request yang memakai API key,
+// request lain dibatasi per user/IP. Dipasang setelah AuthMiddleware
+func KeyByAPIKey(c *gin.Context) string {
+	if id, ok := c.Get("api_key_id"); ok {
+		return fmt.Sprintf("apikey:%v", id)
+	}
+	return KeyByUser(c)
+}
+
+// RateLimit membatasi request dengan token bucket per kunci sesuai policy
+// Setiap pemanggilan membuat limiter sendiri sehingga batas bisa berbeda per grup route
+func RateLimit(policy config.RateLimitPolicy, keyFunc KeyFunc) gin.HandlerFunc {
+	limiter := utils.NewRateLimiter(policy)
+
+	return func(c *gin.Context) {
+		applyRateLimit(c, limiter, keyFunc(c), nil)
+	}
+}
+
+// RateLimitByRole membatasi request sesuai kuota per menit dari role user (utils.QuotaFor)
+// Dipasang setelah AuthMiddleware, role yang tidak dikenal memakai kuota user
+func RateLimitByRole(keyFunc KeyFunc) gin.HandlerFunc {
+	limiters := make(map[string]utils.Limiter)
+	for _, role := range utils.QuotaRoles() {
+		limiters[role] = utils.NewRateLimiter(utils.QuotaFor(role).RateLimitPolicy(role))
+	}
+
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		limiter, ok := limiters[role]
+		if !ok {
+			role = utils.RoleUser
+			limiter = limiters[role]
+		}
+		applyRateLimit(c, limiter, keyFunc(c), gin.H{"tier": role})
+	}
+}
+
+// applyRateLimit mengambil satu kuota, menulis header RateLimit-* dan menolak dengan 429 jika habis
+// extra ditambahkan ke body 429
+func applyRateLimit(c *gin.Context, limiter utils.Limiter, key string, extra gin.H) {
+	result, err := limiter.Allow(c.Request.Context(), key)
+	if err != nil {
+		// Limiter tidak tersedia, request tetap dilayani
+		log.Printf("Rate limiter error: %v", err)
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+		body := gin.H{
+			"error":       "Too many requests",
+			"retry_after": retryAfter,
+			"limit":       result.Limit,
+		}
+		for k, v := range extra {
+			body[k] = v
+		}
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+		return
+	}
+	c.Next()
+}
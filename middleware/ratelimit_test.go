@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"final/config"
+	"final/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimitHeaders tests the RateLimit-* and Retry-After headers and per-user buckets
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/posts", func(c *gin.Context) {
+		SetCurrentUser(c, testUser(c.GetHeader("X-User"), utils.RoleUser))
+		c.Next()
+	}, RateLimit(config.RateLimitPolicy{Rate: 1, Period: time.Minute, Burst: 2}, KeyByUser), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(user string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("X-User", user)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := send("alice")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "2", resp.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header().Get("RateLimit-Remaining"))
+
+	send("alice")
+	resp = send("alice")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+
+	// User lain tidak ikut terkena batas
+	assert.Equal(t, http.StatusOK, send("bob").Code)
+}
+
+// TestRateLimitByRole tests that each role gets the burst of its tier
+func TestRateLimitByRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/posts", func(c *gin.Context) {
+		SetCurrentUser(c, testUser(c.GetHeader("X-User"), c.GetHeader("X-Role")))
+		c.Next()
+	}, RateLimitByRole(KeyByUser), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(user, role string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Role", role)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, strconv.Itoa(utils.QuotaFor(utils.RoleUser).Burst), send("alice", utils.RoleUser).Header().Get("RateLimit-Limit"))
+	assert.Equal(t, strconv.Itoa(utils.QuotaFor(utils.RolePremium).Burst), send("bob", utils.RolePremium).Header().Get("RateLimit-Limit"))
+
+	// Burst user habis, body 429 menyebutkan tier
+	var resp *httptest.ResponseRecorder
+	for i := 0; i < utils.QuotaFor(utils.RoleUser).Burst; i++ {
+		resp = send("alice", utils.RoleUser)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"tier":"user"`)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"final/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail memblokir request dari user yang emailnya belum diverifikasi
+// Hanya aktif jika EMAIL_VERIFICATION_POLICY=write, dipasang setelah AuthMiddleware
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.EmailVerificationPolicy() != "write" {
+			c.Next()
+			return
+		}
+
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  http.StatusUnauthorized,
+				"message": "User tidak terautentikasi",
+			})
+			c.Abort()
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  http.StatusForbidden,
+				"message": "Email belum diverifikasi",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// APIKey adalah key jangka panjang milik user untuk klien mesin (CI, integrasi)
+// Hanya hash-nya yang disimpan, key asli ditampilkan sekali saat dibuat
+type APIKey struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;index" json:"user_id"`
+	Name   string `gorm:"not null;size:100" json:"name"`
+	// Prefix adalah awal key agar user bisa mengenali key di daftar
+	Prefix  string `gorm:"not null;size:16" json:"prefix"`
+	KeyHash string `gorm:"uniqueIndex;not null;size:64" json:"-"`
+	// Scopes adalah permission yang boleh dipakai key, dipisah spasi
+	Scopes     string     `gorm:"not null" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `gorm:"size:45" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
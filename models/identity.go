@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Identity menghubungkan akun di penyedia login luar (OIDC) dengan user lokal
+// Satu user bisa memiliki beberapa identity, satu identity hanya milik satu user
+type Identity struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	// Provider dan Subject (claim sub) unik bersama, sub tidak pernah berubah di penyedia
+	Provider string `gorm:"not null;size:50;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject  string `gorm:"not null;size:255;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	// Email dari penyedia saat terakhir login, hanya informasi
+	Email       string     `gorm:"size:255" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// OAuthState menyimpan state, nonce dan PKCE code verifier antara redirect ke penyedia dan callback
+// Hanya hash state yang disimpan, baris dihapus saat callback sehingga state sekali pakai
+type OAuthState struct {
+	StateHash    string    `gorm:"primaryKey;size:64"`
+	Provider     string    `gorm:"not null;size:50"`
+	CodeVerifier string    `gorm:"not null;size:128"`
+	Nonce        string    `gorm:"not null;size:64"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ImpersonationAudit mencatat setiap token impersonasi yang diterbitkan dan setiap request
+// yang dilakukan dengan token tersebut, beserta admin asli dan user yang ditiru
+type ImpersonationAudit struct {
+	ID      uint `gorm:"primaryKey" json:"id"`
+	AdminID uint `gorm:"not null;index" json:"admin_id"`
+	UserID  uint `gorm:"not null;index" json:"user_id"`
+	// TokenID adalah jti token impersonasi, menghubungkan request dengan saat token diterbitkan
+	TokenID string `gorm:"not null;size:64;index" json:"token_id"`
+	// Action "start" saat token diterbitkan, "request" untuk setiap request dengan token tersebut,
+	// "end" saat admin menghentikan impersonasi sebelum token kedaluwarsa
+	Action string `gorm:"not null;size:16" json:"action"`
+	// Reason alasan admin, hanya diisi untuk action "start"
+	Reason    string    `gorm:"size:255" json:"reason,omitempty"`
+	Method    string    `gorm:"size:10" json:"method"`
+	Path      string    `gorm:"size:255" json:"path"`
+	Status    int       `json:"status"`
+	IP        string    `gorm:"size:45" json:"ip"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// LoginAttempt menyimpan jumlah login gagal per kunci (username atau IP)
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey;size:255" json:"key"`
+	Failures      int       `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time `gorm:"not null" json:"last_failure_at"`
+}
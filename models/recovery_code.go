@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode adalah kode pemulihan 2FA sekali pakai, hanya hash-nya yang disimpan
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null;size:64" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RevokedToken menyimpan jti token yang sudah dicabut sampai token tersebut kedaluwarsa
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
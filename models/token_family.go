@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TokenFamily adalah rangkaian refresh token yang berasal dari satu kali login,
+// sekaligus menjadi sesi yang bisa dilihat dan diakhiri oleh user
+// Jika satu token di dalamnya dipakai ulang, seluruh family dicabut
+type TokenFamily struct {
+	ID     string `gorm:"primaryKey;size:36" json:"id"`
+	UserID uint   `gorm:"not null;index" json:"user_id"`
+	// Perangkat dan alamat IP saat login
+	UserAgent       string     `gorm:"size:255" json:"user_agent"`
+	IP              string     `gorm:"size:45" json:"ip"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// RefreshToken mencatat setiap refresh token yang diterbitkan dalam sebuah family
+type RefreshToken struct {
+	JTI        string     `gorm:"primaryKey;size:64" json:"jti"`
+	FamilyID   string     `gorm:"not null;index;size:36" json:"family_id"`
+	AccessJTI  string     `gorm:"size:64" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// UsageRecord menyimpan jumlah pemakaian operasi berkuota per user per hari (UTC)
+type UsageRecord struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	Day       time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Operation string    `gorm:"primaryKey;size:50" json:"operation"`
+	Count     int       `gorm:"not null;default:0" json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,13 +1,22 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	Username string `gorm:"unique;not null;index" json:"username" binding:"required"`
-	Email    string `gorm:"unique;not null;index" json:"email" binding:"required,email"`
-	Password string `json:"password,omitempty" binding:"required,min=8"`
-	Role     string `gorm:"default:'user'" json:"role"`
-	Posts    []Post `json:"posts,omitempty" gorm:"foreignKey:UserID"`
+	Username        string     `gorm:"unique;not null;index" json:"username" binding:"required"`
+	Email           string     `gorm:"unique;not null;index" json:"email" binding:"required,email"`
+	Password        string     `json:"password,omitempty" binding:"required,min=8"`
+	Role            string     `gorm:"default:'user'" json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TOTPSecret      string     `json:"-"` // terenkripsi, lihat utils.EncryptSecret
+	TOTPEnabled     bool       `gorm:"default:false" json:"totp_enabled"`
+	TOTPLastStep    int64      `json:"-"` // langkah TOTP terakhir yang dipakai, mencegah kode dipakai ulang
+	// TokenVersion disematkan di setiap token, dinaikkan untuk mencabut semua token user
+	TokenVersion uint   `gorm:"not null;default:0" json:"-"`
+	Posts        []Post `json:"posts,omitempty" gorm:"foreignKey:UserID"`
 }
-
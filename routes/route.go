@@ -1,24 +1,24 @@
 package routes
 
 import (
+	"final/config"
 	"final/controllers"
 	"final/middleware"
+	"final/utils"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"golang.org/x/time/rate"
 
 	_ "final/docs" // Import docs untuk Swagger
 )
 
 func LoggingMiddleware() gin.HandlerFunc {
 	return func(context *gin.Context) {
-		startTime := time.Now() // Merekam waktu mulai eksekusi request
-		context.Next()          // Melanjutkan ke handler berikutnya
+		startTime := time.Now()           // Merekam waktu mulai eksekusi request
+		context.Next()                    // Melanjutkan ke handler berikutnya
 		duration := time.Since(startTime) // Menghitung durasi eksekusi
 
 		log.Printf("%s %s | Status: %d | Duration: %s",
@@ -29,68 +29,121 @@ func LoggingMiddleware() gin.HandlerFunc {
 	}
 }
 
-func RateLimitMiddleware(rateLimiter *rate.Limiter) gin.HandlerFunc {
-	return func(context *gin.Context) {
-		if !rateLimiter.Allow() { // Mengecek apakah request diperbolehkan berdasarkan rate limiter
-			context.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
-			context.Abort() // Menghentikan eksekusi request
-			return
-		}
-		context.Next() // Melanjutkan request jika diperbolehkan
-	}
-}
-
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
 
 	// Middleware global
 	r.Use(LoggingMiddleware())
-	
-	// Rate Limiter - meningkatkan batas rate
-	requestLimiter := rate.NewLimiter(5, 10) // 5 request per detik dengan burst 10
-	r.Use(RateLimitMiddleware(requestLimiter))
-	
-	// Cache middleware untuk endpoint GET (30 detik)
-	r.Use(middleware.CacheMiddleware(30 * time.Second))
-	
+
+	// Rate limiter per IP, setiap klien memiliki bucket sendiri
+	r.Use(middleware.RateLimit(config.RateLimitGlobal(), middleware.KeyByIP))
+
+	// Cache untuk endpoint GET (30 detik), dipakai bersama oleh route dan endpoint admin
+	// Backend memori atau Redis sesuai CACHE_STORE
+	responseCache := middleware.NewResponseCache(utils.NewCacheStore(), 30*time.Second)
+	// Handler yang mengubah data menghapus cache berdasarkan tag
+	utils.SetCacheInvalidator(responseCache)
+
 	// Swagger documentation endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Public Auth Routes
-	r.POST("/register", controllers.Register)
-	r.POST("/login", controllers.Login)
-	r.POST("/refresh", controllers.RefreshToken) // Endpoint untuk refresh token
-	
-	// Protected Routes (require valid JWT)
+	// Kunci publik untuk verifikasi token oleh service lain
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+
+	// Public Auth Routes dengan batas per IP yang lebih ketat
+	publicAuth := r.Group("/")
+	publicAuth.Use(middleware.RateLimit(config.RateLimitAuth(), middleware.KeyByIP))
+	publicAuth.POST("/register", controllers.Register)
+	publicAuth.POST("/login", controllers.Login)
+	publicAuth.POST("/login/mfa", controllers.LoginMFA)
+	publicAuth.POST("/password/forgot", controllers.ForgotPassword)
+	publicAuth.POST("/password/reset", controllers.ResetPassword)
+	publicAuth.POST("/refresh", controllers.RefreshToken) // Endpoint untuk refresh token
+	publicAuth.POST("/verify-email", controllers.VerifyEmail)
+	publicAuth.POST("/verify-email/resend", controllers.ResendVerificationEmail)
+
+	// Login lewat penyedia OpenID Connect (authorization code + PKCE)
+	publicAuth.GET("/auth/providers", controllers.ListOAuthProviders)
+	publicAuth.GET("/auth/:provider/login", controllers.OAuthLogin)
+	publicAuth.GET("/auth/:provider/callback", controllers.OAuthCallback)
+
+	// Kuota role per user atau API key, satu limiter dipakai bersama oleh authRoutes dan adminRoutes
+	roleRateLimit := middleware.RateLimitByRole(middleware.KeyByAPIKey)
+
+	// Protected Routes (require valid JWT atau API key), dibatasi per user atau API key sesuai kuota role
 	authRoutes := r.Group("/")
-	authRoutes.Use(middleware.AuthMiddleware())
-	
+	authRoutes.Use(middleware.AuthMiddleware(), roleRateLimit)
+
 	// Logout endpoint
-	authRoutes.POST("/logout", controllers.Logout)
-	
+	authRoutes.POST("/logout", middleware.RequireSession(), controllers.Logout)
+
+	// Admin menghentikan impersonasi lebih awal dengan token impersonasi itu sendiri
+	authRoutes.POST("/impersonation/stop", controllers.StopImpersonation)
+
+	// Pemakaian kuota user yang sedang login
+	authRoutes.GET("/me/usage", controllers.GetMyUsage)
+
+	// Two-factor authentication (TOTP)
+	authRoutes.POST("/2fa/enroll", middleware.RequireSession(), controllers.EnrollTOTP)
+	authRoutes.POST("/2fa/activate", middleware.RequireSession(), controllers.ActivateTOTP)
+	authRoutes.POST("/2fa/disable", middleware.RequireSession(), controllers.DisableTOTP)
+
+	// Sesi login (perangkat) milik user
+	authRoutes.GET("/me/sessions", middleware.RequireSession(), controllers.ListSessions)
+	authRoutes.DELETE("/me/sessions", middleware.RequireSession(), controllers.RevokeOtherSessions)
+	authRoutes.DELETE("/me/sessions/:id", middleware.RequireSession(), controllers.RevokeSession)
+
+	// API key untuk klien mesin, hanya bisa dikelola dengan login biasa (bukan API key)
+	authRoutes.POST("/me/api-keys", middleware.RequireSession(), controllers.CreateAPIKey)
+	authRoutes.GET("/me/api-keys", middleware.RequireSession(), controllers.ListAPIKeys)
+	authRoutes.PATCH("/me/api-keys/:id", middleware.RequireSession(), controllers.UpdateAPIKey)
+	authRoutes.DELETE("/me/api-keys/:id", middleware.RequireSession(), controllers.RevokeAPIKey)
+
 	// User Routes
-	authRoutes.POST("/users", controllers.CreateUser)
-	authRoutes.GET("/users", controllers.GetUsers)
-	authRoutes.GET("/users/:id", controllers.GetUser)
-	authRoutes.PUT("/users/:id", controllers.UpdateUser)
-	authRoutes.DELETE("/users/:id", controllers.DeleteUser)
+	// Cache dipasang per route setelah pengecekan permission agar cache hit tidak melewatinya.
+	// Isi respons sama untuk semua user sehingga cache dibagi tanpa Vary: Authorization
+	authRoutes.GET("/users", middleware.RequirePermission(utils.PermUsersRead), responseCache.Middleware(), controllers.GetUsers)
+	authRoutes.GET("/users/:id", middleware.RequirePermission(utils.PermUsersRead), responseCache.Middleware(), controllers.GetUser)
+	authRoutes.GET("/users/post", middleware.RequirePermission(utils.PermUsersRead), responseCache.Middleware(), controllers.GetUsersWithPosts)
+
+	// Membuat user baru hanya untuk role dengan permission users:manage
+	authRoutes.POST("/users", middleware.RequirePermission(utils.PermUsersManage), controllers.CreateUser)
+
+	// Update/delete user dicek di handler: user itu sendiri atau admin
+	// API key dan token impersonasi tidak bisa mengubah akun agar key yang bocor tidak bisa mengganti password
+	authRoutes.PUT("/users/:id", middleware.RequireSession(), controllers.UpdateUser)
+	authRoutes.DELETE("/users/:id", middleware.RequireSession(), controllers.DeleteUser)
 
 	// Post Routes
-	authRoutes.POST("/posts", controllers.CreatePost)
-	authRoutes.GET("/posts", controllers.GetPosts)
-	authRoutes.GET("/posts/:id", controllers.GetPost)
-	authRoutes.PUT("/posts/:id", controllers.UpdatePost)
-	authRoutes.DELETE("/posts/:id", controllers.DeletePost)
-	authRoutes.GET("/users/post", controllers.GetUsersWithPosts)
+	// Post paling sering dibaca: respons lama tetap dikirim saat cache diperbarui atau database bermasalah
+	postsCachePolicy := middleware.CachePolicy{
+		StaleWhileRevalidate: 30 * time.Second,
+		StaleIfError:         5 * time.Minute,
+	}
+	authRoutes.POST("/posts", middleware.RequirePermission(utils.PermPostsWrite), middleware.RequireVerifiedEmail(), middleware.RequireDailyQuota(utils.OpPostCreate), controllers.CreatePost)
+	authRoutes.GET("/posts", responseCache.MiddlewareWithPolicy(postsCachePolicy), controllers.GetPosts)
+	authRoutes.GET("/posts/:id", responseCache.MiddlewareWithPolicy(postsCachePolicy), controllers.GetPost)
+	authRoutes.PUT("/posts/:id", middleware.RequirePermission(utils.PermPostsWrite), middleware.RequireVerifiedEmail(), controllers.UpdatePost)
+	authRoutes.DELETE("/posts/:id", middleware.RequirePermission(utils.PermPostsWrite), middleware.RequireVerifiedEmail(), controllers.DeletePost)
 
 	// Upload Route
-	authRoutes.POST("/upload", controllers.UploadToCloudinary)
-	
-	// Cache management route (admin only) - tidak ditampilkan di Swagger
+	authRoutes.POST("/upload", middleware.RequirePermission(utils.PermUpload), middleware.RequireVerifiedEmail(), middleware.RequireDailyQuota(utils.OpUpload), controllers.UploadToCloudinary)
+
+	// Admin routes, permission dicek per route, dibatasi sesuai kuota role seperti authRoutes
 	adminRoutes := r.Group("/admin")
-	adminRoutes.Use(middleware.AuthMiddleware())
-	// Gunakan endpoint ini tanpa dokumentasi Swagger
-	adminRoutes.POST("/cache/clear", middleware.ClearCache())
+	adminRoutes.Use(middleware.AuthMiddleware(), roleRateLimit)
+	// Cache management - tidak ditampilkan di Swagger
+	adminRoutes.POST("/cache/clear", middleware.RequirePermission(utils.PermCacheManage), middleware.ClearCache(responseCache))
+	adminRoutes.GET("/cache/stats", middleware.RequirePermission(utils.PermCacheManage), middleware.GetCacheStats(responseCache))
+	adminRoutes.GET("/cache/entries", middleware.RequirePermission(utils.PermCacheManage), middleware.ListCacheEntries(responseCache))
+	adminRoutes.GET("/cache/entries/:key", middleware.RequirePermission(utils.PermCacheManage), middleware.GetCacheEntry(responseCache))
+	// Membuka kunci akun setelah terlalu banyak login gagal
+	adminRoutes.POST("/users/:id/unlock", middleware.RequirePermission(utils.PermUsersManage), controllers.UnlockUser)
+	// Mencabut semua token user (token version dinaikkan)
+	adminRoutes.POST("/users/:id/revoke-tokens", middleware.RequirePermission(utils.PermUsersManage), middleware.RequireSession(), controllers.RevokeUserTokens)
+	// Impersonasi user untuk support, semua request dengan tokennya dicatat di audit log
+	adminRoutes.POST("/users/:id/impersonate", middleware.RequirePermission(utils.PermUsersImpersonate), middleware.RequireSession(), controllers.ImpersonateUser)
+	adminRoutes.GET("/impersonations", middleware.RequirePermission(utils.PermUsersImpersonate), middleware.RequireSession(), controllers.ListImpersonationAudits)
 
 	return r
 }
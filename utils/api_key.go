@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"final/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix adalah awalan semua API key, dipakai untuk membedakannya dari JWT
+// di header Authorization: Bearer
+const APIKeyPrefix = "pk_"
+
+// ErrAPIKeyInvalid dikembalikan jika API key tidak dikenal, sudah dicabut atau kedaluwarsa
+var ErrAPIKeyInvalid = errors.New("API key tidak valid")
+
+// GenerateAPIKey membuat API key acak baru
+func GenerateAPIKey() (string, error) {
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + token, nil
+}
+
+// IsAPIKey mengecek apakah token berbentuk API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// APIKeyDisplayPrefix mengembalikan awal key yang aman ditampilkan di daftar key
+func APIKeyDisplayPrefix(key string) string {
+	return key[:min(len(key), len(APIKeyPrefix)+8)]
+}
+
+// ParseScopes mengubah scope yang disimpan (dipisah spasi) menjadi daftar permission
+func ParseScopes(scopes string) []Permission {
+	fields := strings.Fields(scopes)
+	permissions := make([]Permission, 0, len(fields))
+	for _, field := range fields {
+		permissions = append(permissions, Permission(field))
+	}
+	return permissions
+}
+
+// FormatScopes menyimpan daftar permission sebagai teks dipisah spasi
+func FormatScopes(scopes []Permission) string {
+	fields := make([]string, len(scopes))
+	for i, scope := range scopes {
+		fields[i] = string(scope)
+	}
+	return strings.Join(fields, " ")
+}
+
+// ValidateScopes memastikan setiap scope adalah permission yang dimiliki role
+// Scope duplikat dibuang
+func ValidateScopes(role string, scopes []string) ([]Permission, error) {
+	seen := make(map[Permission]bool)
+	permissions := make([]Permission, 0, len(scopes))
+	for _, scope := range scopes {
+		permission := Permission(scope)
+		if !HasPermission(role, permission) {
+			return nil, fmt.Errorf("scope %q tidak tersedia untuk role %s", scope, role)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// ScopesAllow mengecek apakah scope mengizinkan permission, scope nil berarti
+// request tidak memakai API key sehingga hanya role yang menentukan
+func ScopesAllow(scopes []Permission, permission Permission) bool {
+	if scopes == nil {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthenticateAPIKey mencari API key aktif beserta pemiliknya lalu mencatat waktu dan IP pemakaian
+func AuthenticateAPIKey(db *gorm.DB, key, ip string) (models.APIKey, models.User, error) {
+	var apiKey models.APIKey
+	var user models.User
+
+	now := time.Now()
+	err := db.Where("key_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", HashToken(key), now).
+		First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, user, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return apiKey, user, err
+	}
+
+	if err := db.First(&user, apiKey.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apiKey, user, ErrAPIKeyInvalid
+		}
+		return apiKey, user, err
+	}
+
+	// Pemakaian dicatat paling sering sekali per menit per IP agar tidak menulis di setiap request
+	err = db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ? OR last_used_ip <> ?)", apiKey.ID, now.Add(-time.Minute), ip).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+	return apiKey, user, err
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateAPIKey tests the key format and display prefix
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	assert.Nil(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.False(t, IsAPIKey("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+
+	other, _ := GenerateAPIKey()
+	assert.NotEqual(t, key, other)
+
+	prefix := APIKeyDisplayPrefix(key)
+	assert.Len(t, prefix, len(APIKeyPrefix)+8)
+	assert.Equal(t, key[:len(prefix)], prefix)
+}
+
+// TestValidateScopes tests that scopes are limited to the role's permissions
+func TestValidateScopes(t *testing.T) {
+	scopes, err := ValidateScopes(RoleUser, []string{"posts:write", "uploads:write", "posts:write"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Permission{PermPostsWrite, PermUpload}, scopes)
+	assert.Equal(t, "posts:write uploads:write", FormatScopes(scopes))
+	assert.Equal(t, scopes, ParseScopes(FormatScopes(scopes)))
+
+	_, err = ValidateScopes(RoleUser, []string{"cache:manage"})
+	assert.NotNil(t, err)
+	_, err = ValidateScopes(RoleAdmin, []string{"unknown"})
+	assert.NotNil(t, err)
+
+	assert.True(t, ScopesAllow(nil, PermCacheManage))
+	assert.True(t, ScopesAllow(scopes, PermUpload))
+	assert.False(t, ScopesAllow(scopes, PermUsersRead))
+	assert.False(t, ScopesAllow([]Permission{}, PermUpload))
+
+	// Actor dengan scope dibatasi role dan scope sekaligus
+	actor := Actor{ID: 1, Role: RoleAdmin, Scopes: []Permission{PermPostsWrite}}
+	assert.True(t, actor.Can(PermPostsWrite))
+	assert.False(t, actor.Can(PermPostsModerate))
+	assert.False(t, CanEditPost(actor, 2))
+	assert.True(t, CanEditPost(actor, 1))
+}
@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"container/list"
+	"final/config"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry adalah satu respons yang disimpan di cache
+type CacheEntry struct {
+	Key     string      `json:"key"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Content []byte      `json:"content"`
+	// ETag kuat dari isi respons, dipakai untuk menjawab If-None-Match dengan 304
+	ETag     string    `json:"etag"`
+	StoredAt time.Time `json:"stored_at"`
+	// FreshUntil adalah batas respons boleh dipakai tanpa memperbarui. Setelah itu entry
+	// hanya disimpan sampai ExpiresAt untuk stale-while-revalidate atau stale-if-error
+	FreshUntil time.Time `json:"fresh_until"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Path dicatat agar cache bisa dihapus per prefix
+	Path string `json:"path"`
+	// Tags resource yang ada di respons, contoh "post:42" atau "posts:list".
+	// Respons per user diberi tag user pemiliknya (UserTag)
+	Tags []string `json:"tags,omitempty"`
+}
+
+// IsFresh mengecek apakah entry masih boleh dipakai tanpa memperbarui
+// Entry tanpa FreshUntil segar sampai ExpiresAt
+func (e *CacheEntry) IsFresh(now time.Time) bool {
+	if e.FreshUntil.IsZero() {
+		return now.Before(e.ExpiresAt)
+	}
+	return now.Before(e.FreshUntil)
+}
+
+// Size memperkirakan memori yang dipakai entry dalam byte
+func (e *CacheEntry) Size() int64 {
+	size := int64(len(e.Key) + len(e.Content) + len(e.Path))
+	size += int64(len(e.ETag))
+	for _, tag := range e.Tags {
+		size += int64(len(tag))
+	}
+	for name, values := range e.Header {
+		for _, value := range values {
+			size += int64(len(name) + len(value))
+		}
+	}
+	// Perkiraan overhead struct, map dan list
+	return size + 128
+}
+
+// CacheStore menyimpan respons yang di-cache, bisa dibagi antar instance
+// Semua operasi hapus mengembalikan jumlah entry yang dihapus
+type CacheStore interface {
+	// Get mengembalikan entry yang belum kedaluwarsa dan menandainya sebagai baru dipakai
+	Get(key string) (*CacheEntry, bool, error)
+	// Peek seperti Get tetapi tidak mengubah urutan eviction, untuk endpoint admin
+	Peek(key string) (*CacheEntry, bool, error)
+	// Set menyimpan entry sampai ExpiresAt
+	Set(entry *CacheEntry) error
+	// Epoch mengembalikan nomor invalidasi saat ini, dinaikkan setiap InvalidateTags.
+	// Disimpan di store agar invalidasi di satu replika terlihat oleh replika lain
+	Epoch() (int64, error)
+	// SetIfNotInvalidated menyimpan entry kecuali salah satu tag-nya dihapus setelah epoch
+	// diambil. Mengembalikan false jika entry tidak disimpan
+	SetIfNotInvalidated(entry *CacheEntry, epoch int64) (bool, error)
+	Clear() (int, error)
+	ClearPrefix(prefix string) (int, error)
+	InvalidateTags(tags ...string) (int, error)
+	// Len mengembalikan jumlah entry yang belum kedaluwarsa
+	Len() (int, error)
+	// Prune menghapus entry yang sudah kedaluwarsa
+	Prune() error
+	// List mengembalikan maksimal limit entry yang path-nya diawali prefix, untuk debugging
+	// Seperti Peek, List tidak mengubah urutan eviction
+	List(prefix string, limit int) ([]*CacheEntry, error)
+	// Stats mengembalikan jumlah entry, ukuran dan eviction karena batas kapasitas
+	Stats() (CacheStoreStats, error)
+}
+
+// cacheInvalidationRetention adalah lama catatan invalidasi tag disimpan, harus lebih lama
+// dari request paling lambat yang mengisi cache
+const cacheInvalidationRetention = 10 * time.Minute
+
+// CacheStoreStats adalah kondisi CacheStore saat ini
+type CacheStoreStats struct {
+	Backend string `json:"backend"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+	// MaxBytes 0 berarti batas diatur di luar aplikasi (maxmemory Redis)
+	MaxBytes int64 `json:"max_bytes"`
+	// Evictions adalah entry yang dibuang karena cache penuh, bukan karena dihapus atau kedaluwarsa
+	Evictions int64 `json:"evictions"`
+}
+
+// MemoryCacheStore implementasi CacheStore di memori dengan LRU
+// Dibatasi jumlah item dan total ukuran byte, entry yang paling lama tidak dipakai dibuang lebih dulu
+// Get, Set dan hapus per key berjalan O(1)
+type MemoryCacheStore struct {
+	mutex    sync.Mutex
+	maxItems int
+	maxBytes int64
+	bytes    int64
+	// evictions menghitung entry yang dibuang karena batas item atau byte
+	evictions int64
+	order     *list.List // depan = paling baru dipakai
+	entries   map[string]*list.Element
+	tags      map[string]map[string]struct{}
+	// epoch dan invalidated mencatat kapan tag terakhir dihapus, lihat SetIfNotInvalidated
+	epoch       int64
+	invalidated map[string]tagInvalidation
+}
+
+// tagInvalidation adalah epoch dan waktu saat tag terakhir dihapus
+type tagInvalidation struct {
+	epoch int64
+	at    time.Time
+}
+
+// NewMemoryCacheStore membuat CacheStore di memori
+func NewMemoryCacheStore(maxItems int, maxBytes int64) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxItems:    maxItems,
+		maxBytes:    maxBytes,
+		order:       list.New(),
+		entries:     make(map[string]*list.Element),
+		tags:        make(map[string]map[string]struct{}),
+		invalidated: make(map[string]tagInvalidation),
+	}
+}
+
+// Get mengembalikan entry dan menandainya sebagai baru dipakai
+func (s *MemoryCacheStore) Get(key string) (*CacheEntry, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*CacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		s.remove(key)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(elem)
+	return entry, true, nil
+}
+
+// Peek mengembalikan entry tanpa memindahkannya di urutan LRU
+func (s *MemoryCacheStore) Peek(key string) (*CacheEntry, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*CacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		return nil, false, nil
+	}
+	return entry, true, nil
+}
+
+// Set menyimpan entry lalu membuang entry lama sampai batas item dan byte terpenuhi
+func (s *MemoryCacheStore) Set(entry *CacheEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(entry)
+	return nil
+}
+
+// Epoch mengembalikan nomor invalidasi saat ini
+func (s *MemoryCacheStore) Epoch() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.epoch, nil
+}
+
+// SetIfNotInvalidated menyimpan entry kecuali salah satu tag-nya dihapus setelah epoch
+func (s *MemoryCacheStore) SetIfNotInvalidated(entry *CacheEntry, epoch int64) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tag := range entry.Tags {
+		if s.invalidated[tag].epoch > epoch {
+			return false, nil
+		}
+	}
+	s.set(entry)
+	return true, nil
+}
+
+// set menyimpan entry, dipanggil dengan mutex terkunci
+func (s *MemoryCacheStore) set(entry *CacheEntry) {
+	// Entry yang lebih besar dari seluruh cache tidak disimpan
+	if entry.Size() > s.maxBytes {
+		return
+	}
+
+	s.remove(entry.Key)
+	s.entries[entry.Key] = s.order.PushFront(entry)
+	s.bytes += entry.Size()
+	for _, tag := range entry.Tags {
+		addToIndex(s.tags, tag, entry.Key)
+	}
+
+	for s.order.Len() > s.maxItems || s.bytes > s.maxBytes {
+		s.remove(s.order.Back().Value.(*CacheEntry).Key)
+		s.evictions++
+	}
+}
+
+// Clear menghapus semua entry
+func (s *MemoryCacheStore) Clear() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := s.order.Len()
+	s.order.Init()
+	s.entries = make(map[string]*list.Element)
+	s.tags = make(map[string]map[string]struct{})
+	s.bytes = 0
+	return count, nil
+}
+
+// ClearPrefix menghapus entry yang path-nya diawali prefix
+func (s *MemoryCacheStore) ClearPrefix(prefix string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for key, elem := range s.entries {
+		if strings.HasPrefix(elem.Value.(*CacheEntry).Path, prefix) {
+			s.remove(key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// InvalidateTags menghapus semua entry yang memiliki salah satu tag
+func (s *MemoryCacheStore) InvalidateTags(tags ...string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.epoch++
+	now := time.Now()
+	count := 0
+	for _, tag := range tags {
+		s.invalidated[tag] = tagInvalidation{epoch: s.epoch, at: now}
+		count += s.removeIndexed(s.tags[tag])
+	}
+	return count, nil
+}
+
+// Len mengembalikan jumlah entry
+func (s *MemoryCacheStore) Len() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.order.Len(), nil
+}
+
+// Bytes mengembalikan perkiraan total ukuran entry
+func (s *MemoryCacheStore) Bytes() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.bytes
+}
+
+// Prune menghapus entry yang sudah kedaluwarsa dan catatan invalidasi yang sudah tidak dibutuhkan
+func (s *MemoryCacheStore) Prune() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, elem := range s.entries {
+		if now.After(elem.Value.(*CacheEntry).ExpiresAt) {
+			s.remove(key)
+		}
+	}
+	for tag, invalidation := range s.invalidated {
+		if now.Sub(invalidation.at) > cacheInvalidationRetention {
+			delete(s.invalidated, tag)
+		}
+	}
+	return nil
+}
+
+// List mengembalikan entry yang path-nya diawali prefix, mulai dari yang paling baru dipakai
+func (s *MemoryCacheStore) List(prefix string, limit int) ([]*CacheEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	var entries []*CacheEntry
+	for elem := s.order.Front(); elem != nil && len(entries) < limit; elem = elem.Next() {
+		entry := elem.Value.(*CacheEntry)
+		if now.Before(entry.ExpiresAt) && strings.HasPrefix(entry.Path, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Stats mengembalikan jumlah entry, ukuran dan eviction
+func (s *MemoryCacheStore) Stats() (CacheStoreStats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return CacheStoreStats{
+		Backend:   "memory",
+		Entries:   s.order.Len(),
+		Bytes:     s.bytes,
+		MaxBytes:  s.maxBytes,
+		Evictions: s.evictions,
+	}, nil
+}
+
+// removeIndexed menghapus semua key di dalam indeks, dipanggil dengan mutex terkunci
+func (s *MemoryCacheStore) removeIndexed(index map[string]struct{}) int {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		s.remove(key)
+	}
+	return len(keys)
+}
+
+// remove menghapus entry beserta indeksnya, dipanggil dengan mutex terkunci
+func (s *MemoryCacheStore) remove(key string) {
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*CacheEntry)
+
+	s.order.Remove(elem)
+	delete(s.entries, key)
+	s.bytes -= entry.Size()
+	for _, tag := range entry.Tags {
+		removeFromIndex(s.tags, tag, key)
+	}
+}
+
+// addToIndex menambahkan key ke indeks name
+func addToIndex(index map[string]map[string]struct{}, name, key string) {
+	if index[name] == nil {
+		index[name] = make(map[string]struct{})
+	}
+	index[name][key] = struct{}{}
+}
+
+// removeFromIndex menghapus key dari indeks name
+func removeFromIndex(index map[string]map[string]struct{}, name, key string) {
+	delete(index[name], key)
+	if len(index[name]) == 0 {
+		delete(index, name)
+	}
+}
+
+// NewCacheStore membuat CacheStore sesuai CACHE_STORE
+func NewCacheStore() CacheStore {
+	if config.CacheBackend() == "redis" && config.Redis != nil {
+		return NewRedisCacheStore(config.Redis)
+	}
+	return NewMemoryCacheStore(config.CacheMaxItems(), config.CacheMaxBytes())
+}
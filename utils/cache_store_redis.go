@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Prefix kunci Redis untuk cache respons
+// Semua kunci memakai hash tag {cache} sehingga berada di slot yang sama di Redis Cluster.
+// Script Lua menyusun kunci entry dari isi indeks tag, yang hanya sah jika slotnya sama
+const (
+	redisCacheEntryPrefix = "{cache}:entry:"
+	redisCacheTagPrefix   = "{cache}:tag:"
+	redisCacheKeys        = "{cache}:keys" // sorted set key cache dengan skor waktu kedaluwarsa
+	// redisCacheEpoch dinaikkan setiap invalidasi, redisCacheInvalidatedPrefix menyimpan
+	// epoch terakhir setiap tag dihapus (lihat SetIfNotInvalidated)
+	redisCacheEpoch             = "{cache}:epoch"
+	redisCacheInvalidatedPrefix = "{cache}:invalidated:"
+)
+
+// redisCacheSetScript menyimpan entry beserta indeks tag secara atomik
+// KEYS[1] entry, KEYS[2] sorted set semua key, lalu ARGV[5] indeks tag diikuti catatan invalidasinya.
+// Jika ARGV[6] = "1", entry tidak disimpan bila salah satu tag dihapus setelah epoch ARGV[7].
+// Masa berlaku indeks tidak pernah diperpendek agar key lain di indeks yang sama tetap bisa dihapus
+var redisCacheSetScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+local tags = tonumber(ARGV[5])
+if ARGV[6] == "1" then
+	local epoch = tonumber(ARGV[7])
+	for i = 3 + tags, 2 + 2 * tags do
+		local at = redis.call("GET", KEYS[i])
+		if at and tonumber(at) > epoch then
+			return 0
+		end
+	end
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ttl)
+redis.call("ZADD", KEYS[2], ARGV[3], ARGV[4])
+for i = 3, 2 + tags do
+	redis.call("SADD", KEYS[i], ARGV[4])
+	if redis.call("PTTL", KEYS[i]) < ttl then
+		redis.call("PEXPIRE", KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// redisCacheDeleteIndexScript menghapus semua entry yang terdaftar di indeks tag
+// KEYS[1] adalah sorted set semua key, KEYS[2] epoch invalidasi, KEYS[3..] pasangan
+// indeks tag dan catatan invalidasinya. Catatan disimpan selama ARGV[2] milidetik
+var redisCacheDeleteIndexScript = redis.NewScript(`
+local epoch = redis.call("INCR", KEYS[2])
+local count = 0
+for i = 3, #KEYS, 2 do
+	for _, key in ipairs(redis.call("SMEMBERS", KEYS[i])) do
+		count = count + redis.call("DEL", ARGV[1] .. key)
+		redis.call("ZREM", KEYS[1], key)
+	end
+	redis.call("DEL", KEYS[i])
+	redis.call("SET", KEYS[i + 1], epoch, "PX", ARGV[2])
+end
+return count
+`)
+
+// RedisCacheStore implementasi CacheStore di Redis sehingga cache dibagi ke semua replika
+// Batas memori dan kebijakan eviction diatur oleh Redis (maxmemory)
+type RedisCacheStore struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// NewRedisCacheStore membuat CacheStore yang disimpan di Redis
+func NewRedisCacheStore(client *redis.Client) *RedisCacheStore {
+	return &RedisCacheStore{client: client, timeout: 500 * time.Millisecond}
+}
+
+// context membuat context dengan batas waktu untuk satu operasi
+func (s *RedisCacheStore) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+// Get mengambil entry dari Redis
+func (s *RedisCacheStore) Get(key string) (*CacheEntry, bool, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	data, err := s.client.Get(ctx, redisCacheEntryPrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false, err
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		return nil, false, nil
+	}
+	return &entry, true, nil
+}
+
+// Peek mengambil entry dari Redis, urutan eviction diatur Redis sendiri (maxmemory-policy)
+// sehingga sama dengan Get
+func (s *RedisCacheStore) Peek(key string) (*CacheEntry, bool, error) {
+	return s.Get(key)
+}
+
+// Set menyimpan entry dengan TTL sampai ExpiresAt
+func (s *RedisCacheStore) Set(entry *CacheEntry) error {
+	_, err := s.set(entry, false, 0)
+	return err
+}
+
+// Epoch mengembalikan nomor invalidasi yang dibagi semua replika
+func (s *RedisCacheStore) Epoch() (int64, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	epoch, err := s.client.Get(ctx, redisCacheEpoch).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return epoch, err
+}
+
+// SetIfNotInvalidated menyimpan entry kecuali salah satu tag-nya dihapus setelah epoch,
+// termasuk oleh replika lain. Pengecekan dan penyimpanan berjalan atomik di Redis
+func (s *RedisCacheStore) SetIfNotInvalidated(entry *CacheEntry, epoch int64) (bool, error) {
+	return s.set(entry, true, epoch)
+}
+
+// set menyimpan entry, jika checkEpoch entry tidak disimpan bila tag-nya dihapus setelah epoch
+func (s *RedisCacheStore) set(entry *CacheEntry, checkEpoch bool, epoch int64) (bool, error) {
+	ttl := time.Until(entry.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+
+	keys := []string{redisCacheEntryPrefix + entry.Key, redisCacheKeys}
+	for _, tag := range entry.Tags {
+		keys = append(keys, redisCacheTagPrefix+tag)
+	}
+	for _, tag := range entry.Tags {
+		keys = append(keys, redisCacheInvalidatedPrefix+tag)
+	}
+	check := "0"
+	if checkEpoch {
+		check = "1"
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	stored, err := redisCacheSetScript.Run(ctx, s.client, keys,
+		data, ttl, entry.ExpiresAt.UnixMilli(), entry.Key, len(entry.Tags), check, epoch).Int()
+	return stored == 1, err
+}
+
+// Clear menghapus semua entry
+func (s *RedisCacheStore) Clear() (int, error) {
+	return s.deleteMatching(func(*CacheEntry) bool { return true })
+}
+
+// ClearPrefix menghapus entry yang path-nya diawali prefix
+func (s *RedisCacheStore) ClearPrefix(prefix string) (int, error) {
+	return s.deleteMatching(func(entry *CacheEntry) bool { return strings.HasPrefix(entry.Path, prefix) })
+}
+
+// InvalidateTags menghapus semua entry yang memiliki salah satu tag dan mencatat epoch
+// invalidasinya agar respons yang sedang dibuat di replika mana pun tidak disimpan
+func (s *RedisCacheStore) InvalidateTags(tags ...string) (int, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	keys := []string{redisCacheKeys, redisCacheEpoch}
+	for _, tag := range tags {
+		keys = append(keys, redisCacheTagPrefix+tag, redisCacheInvalidatedPrefix+tag)
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	return redisCacheDeleteIndexScript.Run(ctx, s.client, keys,
+		redisCacheEntryPrefix, cacheInvalidationRetention.Milliseconds()).Int()
+}
+
+// Len mengembalikan jumlah entry yang belum kedaluwarsa
+func (s *RedisCacheStore) Len() (int, error) {
+	if err := s.Prune(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	count, err := s.client.ZCard(ctx, redisCacheKeys).Result()
+	return int(count), err
+}
+
+// Prune menghapus key kedaluwarsa dari daftar key, entry-nya sendiri sudah dihapus oleh TTL Redis
+func (s *RedisCacheStore) Prune() error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.ZRemRangeByScore(ctx, redisCacheKeys, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10)).Err()
+}
+
+// deleteMatching memeriksa semua entry per batch dan menghapus yang cocok
+// Hanya dipakai oleh operasi admin karena harus membaca seluruh entry
+func (s *RedisCacheStore) deleteMatching(match func(*CacheEntry) bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*s.timeout)
+	defer cancel()
+
+	count := 0
+	err := s.scan(ctx, func(keys []string, entries []*CacheEntry, _ []int) (bool, error) {
+		var matched []string
+		var stale []interface{}
+		for i, entry := range entries {
+			if entry == nil {
+				// Entry sudah kedaluwarsa
+				stale = append(stale, keys[i])
+				continue
+			}
+			if match(entry) {
+				matched = append(matched, redisCacheEntryPrefix+keys[i])
+				stale = append(stale, keys[i])
+			}
+		}
+
+		if len(matched) > 0 {
+			deleted, err := s.client.Del(ctx, matched...).Result()
+			if err != nil {
+				return false, err
+			}
+			count += int(deleted)
+		}
+		if len(stale) > 0 {
+			if err := s.client.ZRem(ctx, redisCacheKeys, stale...).Err(); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	return count, err
+}
+
+// List mengembalikan entry yang path-nya diawali prefix, diurutkan dari yang paling cepat kedaluwarsa
+func (s *RedisCacheStore) List(prefix string, limit int) ([]*CacheEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*s.timeout)
+	defer cancel()
+
+	var result []*CacheEntry
+	err := s.scan(ctx, func(_ []string, entries []*CacheEntry, _ []int) (bool, error) {
+		for _, entry := range entries {
+			if entry != nil && strings.HasPrefix(entry.Path, prefix) {
+				result = append(result, entry)
+				if len(result) >= limit {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+	return result, err
+}
+
+// Stats menghitung jumlah dan ukuran entry dengan membaca seluruh entry
+// Eviction oleh maxmemory Redis tidak terlihat dari aplikasi sehingga selalu 0
+func (s *RedisCacheStore) Stats() (CacheStoreStats, error) {
+	stats := CacheStoreStats{Backend: "redis"}
+	if err := s.Prune(); err != nil {
+		return stats, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*s.timeout)
+	defer cancel()
+
+	err := s.scan(ctx, func(_ []string, entries []*CacheEntry, sizes []int) (bool, error) {
+		for i, entry := range entries {
+			if entry != nil {
+				stats.Entries++
+				stats.Bytes += int64(sizes[i])
+			}
+		}
+		return true, nil
+	})
+	return stats, err
+}
+
+// scan membaca semua entry per batch dan memanggil visit untuk setiap batch
+// Entry yang sudah kedaluwarsa bernilai nil, sizes berisi ukuran data di Redis.
+// visit mengembalikan false untuk berhenti
+func (s *RedisCacheStore) scan(ctx context.Context, visit func(keys []string, entries []*CacheEntry, sizes []int) (bool, error)) error {
+	keys, err := s.client.ZRange(ctx, redisCacheKeys, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	const batchSize = 100
+	for start := 0; start < len(keys); start += batchSize {
+		batch := keys[start:min(start+batchSize, len(keys))]
+
+		entryKeys := make([]string, len(batch))
+		for i, key := range batch {
+			entryKeys[i] = redisCacheEntryPrefix + key
+		}
+		values, err := s.client.MGet(ctx, entryKeys...).Result()
+		if err != nil {
+			return err
+		}
+
+		entries := make([]*CacheEntry, len(values))
+		sizes := make([]int, len(values))
+		for i, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var entry CacheEntry
+			if json.Unmarshal([]byte(data), &entry) == nil {
+				entries[i] = &entry
+				sizes[i] = len(data)
+			}
+		}
+
+		more, err := visit(batch, entries, sizes)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
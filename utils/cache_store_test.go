@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newEntry membuat entry cache untuk test
+func newEntry(key, path string, tags ...string) *CacheEntry {
+	return &CacheEntry{
+		Key:       key,
+		Content:   []byte(`{"data":"` + key + `"}`),
+		ExpiresAt: time.Now().Add(time.Minute),
+		Path:      path,
+		Tags:      tags,
+	}
+}
+
+// testCacheStore menjalankan pengujian yang sama untuk setiap implementasi CacheStore
+func testCacheStore(t *testing.T, store CacheStore) {
+	count := func() int {
+		n, err := store.Len()
+		assert.Nil(t, err)
+		return n
+	}
+	fill := func() {
+		assert.Nil(t, store.Set(newEntry("a", "/posts", "posts:list", "post:1", "user:1")))
+		assert.Nil(t, store.Set(newEntry("b", "/posts/1", "post:1", "user:1")))
+		assert.Nil(t, store.Set(newEntry("c", "/posts/2", "post:2", "user:2")))
+		assert.Nil(t, store.Set(newEntry("d", "/users", "users:list", "user:2")))
+	}
+
+	fill()
+	entry, ok, err := store.Get("b")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "/posts/1", entry.Path)
+	assert.Equal(t, []string{"post:1", "user:1"}, entry.Tags)
+
+	_, ok, _ = store.Get("missing")
+	assert.False(t, ok)
+
+	// Entry yang sudah kedaluwarsa tidak pernah dikembalikan
+	expired := newEntry("old", "/posts")
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	store.Set(expired)
+	_, ok, _ = store.Get("old")
+	assert.False(t, ok)
+
+	listed, err := store.List("/posts", 10)
+	assert.Nil(t, err)
+	assert.Len(t, listed, 3)
+	listed, _ = store.List("", 2)
+	assert.Len(t, listed, 2)
+
+	stats, err := store.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, stats.Entries)
+	assert.Greater(t, stats.Bytes, int64(0))
+
+	deleted, err := store.InvalidateTags("post:1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 2, count())
+
+	deleted, _ = store.InvalidateTags("user:2")
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 0, count())
+
+	fill()
+	deleted, _ = store.ClearPrefix("/posts")
+	assert.Equal(t, 3, deleted)
+	_, ok, _ = store.Get("d")
+	assert.True(t, ok)
+
+	fill()
+	deleted, _ = store.Clear()
+	assert.Equal(t, 4, deleted)
+	assert.Equal(t, 0, count())
+
+	// Entry tidak disimpan jika tag-nya dihapus setelah epoch diambil
+	epoch, err := store.Epoch()
+	assert.Nil(t, err)
+	store.InvalidateTags("post:1")
+	stored, err := store.SetIfNotInvalidated(newEntry("e", "/posts/1", "post:1"), epoch)
+	assert.Nil(t, err)
+	assert.False(t, stored)
+	stored, _ = store.SetIfNotInvalidated(newEntry("f", "/posts/2", "post:2"), epoch)
+	assert.True(t, stored)
+
+	epoch, _ = store.Epoch()
+	stored, _ = store.SetIfNotInvalidated(newEntry("e", "/posts/1", "post:1"), epoch)
+	assert.True(t, stored)
+	assert.Equal(t, 2, count())
+}
+
+// TestMemoryCacheStore tests the in-memory store
+func TestMemoryCacheStore(t *testing.T) {
+	testCacheStore(t, NewMemoryCacheStore(100, 1<<20))
+}
+
+// TestRedisCacheStore tests the Redis store against an in-process Redis
+func TestRedisCacheStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	testCacheStore(t, NewRedisCacheStore(redis.NewClient(&redis.Options{Addr: server.Addr()})))
+
+	// Semua kunci memakai hash tag yang sama agar script tetap sah di Redis Cluster
+	assert.NotEmpty(t, server.Keys())
+	for _, key := range server.Keys() {
+		assert.True(t, strings.HasPrefix(key, "{cache}:"), key)
+	}
+}
+
+// TestMemoryCacheStoreLRU tests eviction by item count and by size in bytes
+func TestMemoryCacheStoreLRU(t *testing.T) {
+	store := NewMemoryCacheStore(3, 1<<20)
+	for i := 0; i < 3; i++ {
+		store.Set(newEntry(fmt.Sprint(i), "/posts"))
+	}
+
+	// 0 dipakai sehingga 1 menjadi yang paling lama tidak dipakai
+	store.Get("0")
+	store.Set(newEntry("3", "/posts"))
+
+	_, ok, _ := store.Get("1")
+	assert.False(t, ok)
+	_, ok, _ = store.Get("0")
+	assert.True(t, ok)
+
+	// Batas byte: hanya dua entry yang muat
+	entry := newEntry("big", "/posts")
+	bySize := NewMemoryCacheStore(100, 2*entry.Size())
+	bySize.Set(newEntry("x", "/posts", "post:1"))
+	bySize.Set(newEntry("y", "/posts"))
+	bySize.Set(newEntry("z", "/posts"))
+
+	count, _ := bySize.Len()
+	assert.Equal(t, 2, count)
+	assert.LessOrEqual(t, bySize.Bytes(), 2*entry.Size())
+	_, ok, _ = bySize.Get("x")
+	assert.False(t, ok)
+
+	// Indeks tag ikut dibersihkan saat entry dibuang
+	deleted, _ := bySize.InvalidateTags("post:1")
+	assert.Equal(t, 0, deleted)
+
+	stats, _ := store.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	stats, _ = bySize.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+}
+
+// TestMemoryCacheStorePeek tests that Peek and List do not change the eviction order
+func TestMemoryCacheStorePeek(t *testing.T) {
+	store := NewMemoryCacheStore(2, 1<<20)
+	store.Set(newEntry("0", "/posts"))
+	store.Set(newEntry("1", "/posts"))
+
+	// Peek tidak membuat 0 menjadi baru dipakai, sehingga 0 tetap dibuang lebih dulu
+	entry, ok, err := store.Peek("0")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "0", entry.Key)
+	store.List("", 10)
+	store.Set(newEntry("2", "/posts"))
+
+	_, ok, _ = store.Peek("0")
+	assert.False(t, ok)
+	_, ok, _ = store.Peek("1")
+	assert.True(t, ok)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CacheTagsKey adalah key gin.Context untuk tag respons yang akan disimpan di cache
+const CacheTagsKey = "cache_tags"
+
+// Tag untuk daftar resource, dihapus setiap kali ada resource yang dibuat atau dihapus
+const (
+	TagPostsList = "posts:list"
+	TagUsersList = "users:list"
+)
+
+// PostTag adalah tag untuk respons yang berisi post tertentu
+func PostTag(id uint) string {
+	return fmt.Sprintf("post:%d", id)
+}
+
+// UserTag adalah tag untuk respons yang berisi user tertentu
+func UserTag(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+// CacheInvalidator menghapus entri cache yang memiliki salah satu tag
+type CacheInvalidator interface {
+	InvalidateTags(tags ...string) int
+}
+
+// noopInvalidator dipakai jika belum ada cache yang diatur
+type noopInvalidator struct{}
+
+func (noopInvalidator) InvalidateTags(tags ...string) int { return 0 }
+
+// Invalidator yang digunakan aplikasi
+var (
+	cacheInvalidator      CacheInvalidator = noopInvalidator{}
+	cacheInvalidatorMutex sync.RWMutex
+)
+
+// SetCacheInvalidator mengatur cache yang dibersihkan saat data berubah, nil untuk menonaktifkan
+func SetCacheInvalidator(invalidator CacheInvalidator) {
+	if invalidator == nil {
+		invalidator = noopInvalidator{}
+	}
+	cacheInvalidatorMutex.Lock()
+	cacheInvalidator = invalidator
+	cacheInvalidatorMutex.Unlock()
+}
+
+// GetCacheInvalidator mengembalikan invalidator yang aktif
+func GetCacheInvalidator() CacheInvalidator {
+	cacheInvalidatorMutex.RLock()
+	defer cacheInvalidatorMutex.RUnlock()
+	return cacheInvalidator
+}
+
+// InvalidateCacheTags menghapus entri cache dengan tag tertentu
+// Dipanggil setelah perubahan data berhasil disimpan (setelah commit)
+func InvalidateCacheTags(tags ...string) int {
+	return GetCacheInvalidator().InvalidateTags(tags...)
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"final/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// CurrentUser adalah user yang sedang melakukan request beserta cara autentikasinya
+// Dimuat sekali oleh AuthMiddleware sehingga handler tidak perlu mencari user lagi
+type CurrentUser struct {
+	models.User
+	// APIKeyID bukan 0 jika request memakai API key, Scopes membatasi permission key tersebut
+	APIKeyID uint
+	Scopes   []Permission
+	// SessionID adalah token family dari access token (claim sid), kosong untuk API key
+	SessionID string
+	// Claims access token, nil untuk API key
+	Claims *jwt.MapClaims
+	// Impersonator adalah admin yang sebenarnya melakukan request lewat token impersonasi
+	Impersonator *models.User
+}
+
+// Actor mengembalikan user sebagai Actor untuk pengecekan policy
+func (u *CurrentUser) Actor() Actor {
+	return Actor{ID: u.ID, Username: u.Username, Role: u.Role, Scopes: u.Scopes}
+}
+
+// IsAPIKey mengecek apakah request memakai API key, bukan sesi login
+func (u *CurrentUser) IsAPIKey() bool {
+	return u.APIKeyID != 0
+}
+
+// IsImpersonated mengecek apakah request dilakukan admin atas nama user ini
+func (u *CurrentUser) IsImpersonated() bool {
+	return u.Impersonator != nil
+}
+
+// BumpTokenVersion menaikkan token version user sehingga semua access dan refresh token
+// yang sudah terbit langsung ditolak, termasuk yang masih ada di cache verifikasi
+func BumpTokenVersion(db *gorm.DB, userID uint) error {
+	err := db.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+	if err != nil {
+		return err
+	}
+	InvalidateCachedUserTokens(userID)
+	return nil
+}
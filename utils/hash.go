@@ -2,60 +2,180 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"final/config"
 	"fmt"
 	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Format hash yang didukung:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>  format PHC, default untuk hash baru
+//	$2a$12$...                                    bcrypt standar
+//	<salt>:<bcrypt hash>                          format lama, hanya untuk verifikasi
+const (
+	argon2KeyLength  = 32
+	argon2SaltLength = 16
+)
+
+// ErrUnsupportedHash format hash password tidak dikenal
+var ErrUnsupportedHash = errors.New("format hash password tidak dikenal")
+
+// argon2Params parameter argon2id yang tersimpan di dalam hash
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
 // generateSalt creates a random salt of specified length
-func generateSalt(length int) (string, error) {
-    bytes := make([]byte, length)
-    _, err := rand.Read(bytes)
-    if err != nil {
-        return "", err
-    }
-    return base64.StdEncoding.EncodeToString(bytes), nil
+func generateSalt(length int) ([]byte, error) {
+	bytes := make([]byte, length)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return bytes, nil
 }
 
-// HashPassword hashes the password with a custom salt
+// HashPassword hashes the password with the configured algorithm (argon2id by default)
 func HashPassword(password string) (string, error) {
-    // Generate salt (16 bytes is common)
-    salt, err := generateSalt(16)
-    if err != nil {
-        return "", err
-    }
-    
-    // Combine password with salt
-    saltedPassword := fmt.Sprintf("%s%s", password, salt)
-    
-    // Hash the salted password
-    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(saltedPassword), config.BCryptCost())
-    if err != nil {
-        return "", err
-    }
-    
-    // Store both the salt and hash to be able to verify later
-    // Format: base64(salt):base64(hash)
-    return fmt.Sprintf("%s:%s", salt, string(hashedPassword)), nil
-}
-
-// CheckPasswordHash verifies if the password matches the stored hash
+	switch config.PasswordHashAlgorithm() {
+	case "argon2id":
+		return hashArgon2id(password, argon2Params{
+			memory:  config.Argon2Memory(),
+			time:    config.Argon2Time(),
+			threads: config.Argon2Threads(),
+		})
+	case "bcrypt":
+		// bcrypt menolak password lebih dari 72 byte daripada memotongnya diam-diam
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), config.BCryptCost())
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPassword), nil
+	default:
+		return "", fmt.Errorf("PASSWORD_HASH_ALG tidak dikenal: %s", config.PasswordHashAlgorithm())
+	}
+}
+
+// CheckPasswordHash verifies if the password matches the stored hash in any supported format
 func CheckPasswordHash(password, storedValue string) bool {
-    // Split the stored value to get the salt and hash
-    parts := strings.Split(storedValue, ":")
-    if len(parts) != 2 {
-        return false
-    }
-    
-    salt, storedHash := parts[0], parts[1]
-    
-    // Recreate the salted password
-    saltedPassword := fmt.Sprintf("%s%s", password, salt)
-    
-    // Compare the hash
-    err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(saltedPassword))
-    return err == nil
-}
\ No newline at end of file
+	switch {
+	case strings.HasPrefix(storedValue, "$argon2id$"):
+		params, salt, hash, err := decodeArgon2id(storedValue)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(hash)))
+		return subtle.ConstantTimeCompare(hash, other) == 1
+	case isBcryptHash(storedValue):
+		return bcrypt.CompareHashAndPassword([]byte(storedValue), []byte(password)) == nil
+	default:
+		return checkLegacyHash(password, storedValue)
+	}
+}
+
+// NeedsRehash mengecek apakah hash perlu diperbarui karena memakai skema lama
+// atau parameter yang lebih lemah dari konfigurasi saat ini
+func NeedsRehash(storedValue string) bool {
+	algorithm := config.PasswordHashAlgorithm()
+
+	switch {
+	case strings.HasPrefix(storedValue, "$argon2id$"):
+		if algorithm != "argon2id" {
+			return true
+		}
+		params, _, hash, err := decodeArgon2id(storedValue)
+		if err != nil {
+			return true
+		}
+		return params.memory < config.Argon2Memory() ||
+			params.time < config.Argon2Time() ||
+			params.threads < config.Argon2Threads() ||
+			len(hash) < argon2KeyLength
+	case isBcryptHash(storedValue):
+		if algorithm != "bcrypt" {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(storedValue))
+		return err != nil || cost < config.BCryptCost()
+	default:
+		// Format lama salt:hash selalu di-upgrade
+		return true
+	}
+}
+
+// hashArgon2id membuat hash argon2id dalam format PHC
+func hashArgon2id(password string, params argon2Params) (string, error) {
+	salt, err := generateSalt(argon2SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// decodeArgon2id membaca parameter, salt dan hash dari string PHC argon2id
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	return params, salt, hash, nil
+}
+
+// isBcryptHash mengecek prefix hash bcrypt standar
+func isBcryptHash(storedValue string) bool {
+	return strings.HasPrefix(storedValue, "$2a$") ||
+		strings.HasPrefix(storedValue, "$2b$") ||
+		strings.HasPrefix(storedValue, "$2y$")
+}
+
+// checkLegacyHash memverifikasi format lama base64(salt):bcrypt(password+salt)
+func checkLegacyHash(password, storedValue string) bool {
+	// Split the stored value to get the salt and hash
+	parts := strings.SplitN(storedValue, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	salt, storedHash := parts[0], parts[1]
+
+	// Recreate the salted password
+	saltedPassword := fmt.Sprintf("%s%s", password, salt)
+
+	// Compare the hash
+	err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(saltedPassword))
+	return err == nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// legacyHash builds a hash in the old salt:hash layout
+func legacyHash(t *testing.T, password string) string {
+	salt := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	hash, err := bcrypt.GenerateFromPassword([]byte(password+salt), bcrypt.MinCost)
+	assert.Nil(t, err)
+	return salt + ":" + string(hash)
+}
+
+// TestHashPasswordArgon2id tests the default PHC argon2id format
+func TestHashPasswordArgon2id(t *testing.T) {
+	hash, err := HashPassword("password123")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$"))
+
+	assert.True(t, CheckPasswordHash("password123", hash))
+	assert.False(t, CheckPasswordHash("password124", hash))
+	assert.False(t, NeedsRehash(hash))
+}
+
+// TestHashPasswordLongInput tests that characters past byte 72 still matter
+func TestHashPasswordLongInput(t *testing.T) {
+	prefix := strings.Repeat("a", 80)
+	hash, err := HashPassword(prefix + "1")
+	assert.Nil(t, err)
+	assert.False(t, CheckPasswordHash(prefix+"2", hash))
+}
+
+// TestCheckPasswordHashLegacy tests that legacy salt:hash values still verify and need a rehash
+func TestCheckPasswordHashLegacy(t *testing.T) {
+	stored := legacyHash(t, "password123")
+
+	assert.True(t, CheckPasswordHash("password123", stored))
+	assert.False(t, CheckPasswordHash("wrong", stored))
+	assert.True(t, NeedsRehash(stored))
+}
+
+// TestNeedsRehashBcrypt tests bcrypt hashes against the configured algorithm and cost
+func TestNeedsRehashBcrypt(t *testing.T) {
+	weak, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.True(t, CheckPasswordHash("password123", string(weak)))
+
+	// argon2id is configured, so any bcrypt hash gets upgraded
+	assert.True(t, NeedsRehash(string(weak)))
+
+	// With bcrypt configured only a lower cost triggers an upgrade
+	t.Setenv("PASSWORD_HASH_ALG", "bcrypt")
+	assert.True(t, NeedsRehash(string(weak)))
+
+	strong, err := HashPassword("password123")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(strong, "$2a$12$"))
+	assert.False(t, NeedsRehash(strong))
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"errors"
+	"final/config"
+	"final/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOAuthStateInvalid state tidak dikenal, sudah dipakai, kedaluwarsa atau untuk penyedia lain
+	ErrOAuthStateInvalid = errors.New("state OAuth tidak valid")
+	// ErrIdentityEmailUnverified penyedia tidak menjamin email milik user sehingga akun tidak bisa dibuat atau ditautkan
+	ErrIdentityEmailUnverified = errors.New("email dari penyedia belum diverifikasi")
+	// ErrIdentityLinkRequiresLogin ada akun lokal dengan email yang sama tetapi emailnya belum diverifikasi
+	ErrIdentityLinkRequiresLogin = errors.New("akun dengan email ini harus diverifikasi sebelum bisa ditautkan")
+)
+
+// OAuthLoginState adalah nilai yang dibuat saat login dimulai
+// State dikirim ke penyedia dan disimpan di cookie, verifier dan nonce tetap di server
+type OAuthLoginState struct {
+	State         string
+	CodeChallenge string
+	Nonce         string
+}
+
+// CreateOAuthState membuat state, nonce dan PKCE code verifier untuk satu kali login
+func CreateOAuthState(db *gorm.DB, provider string) (*OAuthLoginState, error) {
+	state, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := NewPKCEVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	// State dari login yang ditinggalkan sebelum callback ikut dibersihkan
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{}).Error; err != nil {
+		return nil, err
+	}
+
+	err = db.Create(&models.OAuthState{
+		StateHash:    HashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(config.OAuthStateExpiryTime()),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthLoginState{State: state, CodeChallenge: PKCEChallenge(verifier), Nonce: nonce}, nil
+}
+
+// ConsumeOAuthState mengambil dan menghapus state sehingga callback yang sama tidak bisa diulang
+func ConsumeOAuthState(db *gorm.DB, provider, state string) (*models.OAuthState, error) {
+	var stored models.OAuthState
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", HashToken(state)).First(&stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOAuthStateInvalid
+			}
+			return err
+		}
+		// Hanya satu callback yang berhasil menghapus baris ini
+		result := tx.Where("state_hash = ?", stored.StateHash).Delete(&models.OAuthState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOAuthStateInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Provider != provider || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrOAuthStateInvalid
+	}
+	return &stored, nil
+}
+
+// ResolveIdentity mencari atau membuat user lokal untuk identitas dari penyedia
+//  1. Identity yang sudah tertaut langsung dipakai
+//  2. Jika belum, email yang diverifikasi penyedia ditautkan ke user dengan email yang sama,
+//     asalkan email user lokal juga sudah diverifikasi. Tanpa syarat ini siapa pun bisa
+//     mendaftar dengan email orang lain lalu menunggu pemilik asli login lewat penyedia
+//  3. Jika tidak ada user dengan email tersebut, user baru dibuat dengan email terverifikasi
+//
+// created bernilai true jika user baru dibuat
+func ResolveIdentity(db *gorm.DB, external *ExternalIdentity) (user models.User, created bool, err error) {
+	now := time.Now()
+
+	var identity models.Identity
+	err = db.Where("provider = ? AND subject = ?", external.Provider, external.Subject).First(&identity).Error
+	if err == nil {
+		if err = db.First(&user, identity.UserID).Error; err != nil {
+			return user, false, err
+		}
+		err = db.Model(&identity).Updates(map[string]interface{}{"email": external.Email, "last_login_at": now}).Error
+		return user, false, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, false, err
+	}
+
+	if external.Email == "" || !external.EmailVerified {
+		return user, false, ErrIdentityEmailUnverified
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		lookup := tx.Where("LOWER(email) = ?", strings.ToLower(external.Email)).First(&user)
+		switch {
+		case lookup.Error == nil:
+			if user.EmailVerifiedAt == nil {
+				return ErrIdentityLinkRequiresLogin
+			}
+		case errors.Is(lookup.Error, gorm.ErrRecordNotFound):
+			user, err = createExternalUser(tx, external, now)
+			if err != nil {
+				return err
+			}
+			created = true
+		default:
+			return lookup.Error
+		}
+
+		return tx.Create(&models.Identity{
+			UserID:      user.ID,
+			Provider:    external.Provider,
+			Subject:     external.Subject,
+			Email:       external.Email,
+			LastLoginAt: &now,
+		}).Error
+	})
+	return user, created, err
+}
+
+// createExternalUser membuat user untuk login pertama lewat penyedia
+// Password diisi hash dari nilai acak, user bisa mengatur password lewat /password/forgot
+func createExternalUser(db *gorm.DB, external *ExternalIdentity, now time.Time) (models.User, error) {
+	randomPassword, err := GenerateSecureToken(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := HashPassword(randomPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+	username, err := availableUsername(db, external)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Username:        username,
+		Email:           external.Email,
+		Password:        hashedPassword,
+		Role:            "user",
+		EmailVerifiedAt: &now,
+	}
+	return user, db.Create(&user).Error
+}
+
+// usernameDisallowed adalah karakter yang tidak dipakai di username dari penyedia
+var usernameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// availableUsername menurunkan username dari preferred_username atau bagian depan email
+// lalu menambahkan akhiran acak jika sudah dipakai, panjangnya mengikuti aturan Register (3-50)
+func availableUsername(db *gorm.DB, external *ExternalIdentity) (string, error) {
+	base := external.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(external.Email, "@")
+	}
+	base = usernameDisallowed.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		var count int64
+		if err := db.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix, err := GenerateSecureToken(3)
+		if err != nil {
+			return "", err
+		}
+		candidate = base + suffix
+	}
+	return "", errors.New("gagal menemukan username yang tersedia")
+}
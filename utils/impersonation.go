@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"final/config"
+	"final/models"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Aksi yang dicatat di models.ImpersonationAudit
+const (
+	ImpersonationStart   = "start"
+	ImpersonationRequest = "request"
+	ImpersonationEnd     = "end"
+)
+
+// GenerateImpersonationToken membuat access token berumur pendek atas nama target
+// Claim act (RFC 8693) berisi admin yang sebenarnya beserta token version-nya sehingga
+// mencabut token admin juga menghentikan impersonasi. Tidak ada refresh token
+func GenerateImpersonationToken(admin, target models.User) (*TokenDetails, error) {
+	td := &TokenDetails{
+		AccessUUID: uuid.NewString(),
+		AtExpires:  time.Now().Add(time.Minute * time.Duration(config.ImpersonationExpiryTime())).Unix(),
+	}
+
+	claims := jwt.MapClaims{
+		"sub":  strconv.FormatUint(uint64(target.ID), 10),
+		"role": target.Role,
+		"ver":  target.TokenVersion,
+		"act": map[string]interface{}{
+			"sub": strconv.FormatUint(uint64(admin.ID), 10),
+			"ver": admin.TokenVersion,
+		},
+		"exp":  td.AtExpires,
+		"jti":  td.AccessUUID,
+		"type": "access",
+	}
+
+	var err error
+	td.AccessToken, err = GetKeyring().Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// TokenActor membaca admin dari claim act
+// ok false berarti token bukan token impersonasi
+func TokenActor(claims *jwt.MapClaims) (adminID uint, version uint, ok bool, err error) {
+	value, exists := (*claims)["act"]
+	if !exists {
+		return 0, 0, false, nil
+	}
+
+	act, isMap := value.(map[string]interface{})
+	if !isMap {
+		return 0, 0, true, errors.New("invalid act claim")
+	}
+	sub, _ := act["sub"].(string)
+	id, parseErr := strconv.ParseUint(sub, 10, 64)
+	if parseErr != nil || id == 0 {
+		return 0, 0, true, errors.New("invalid act claim")
+	}
+	ver, _ := act["ver"].(float64)
+	return uint(id), uint(ver), true, nil
+}
+
+// CanImpersonate mengecek apakah admin boleh masuk sebagai target
+// Admin tidak bisa meniru dirinya sendiri atau user lain yang juga bisa impersonasi
+func CanImpersonate(admin, target models.User) bool {
+	return admin.ID != target.ID &&
+		HasPermission(admin.Role, PermUsersImpersonate) &&
+		!HasPermission(target.Role, PermUsersImpersonate)
+}
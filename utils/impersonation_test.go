@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"final/models"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestGenerateImpersonationToken tests that the token acts as the target and names the admin in the act claim
+func TestGenerateImpersonationToken(t *testing.T) {
+	admin := models.User{Model: gorm.Model{ID: 1}, Role: RoleAdmin, TokenVersion: 2}
+	target := models.User{Model: gorm.Model{ID: 42}, Role: RoleUser, TokenVersion: 5}
+
+	td, err := GenerateImpersonationToken(admin, target)
+	assert.Nil(t, err)
+	assert.Empty(t, td.RefreshToken)
+
+	claims, err := ParseJWT(td.AccessToken)
+	assert.Nil(t, err)
+	userID, _ := TokenUserID(claims)
+	assert.Equal(t, uint(42), userID)
+	assert.Equal(t, uint(5), TokenVersion(claims))
+	assert.Equal(t, td.AccessUUID, (*claims)["jti"])
+	assert.Nil(t, (*claims)["sid"])
+
+	adminID, adminVersion, ok, err := TokenActor(claims)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint(1), adminID)
+	assert.Equal(t, uint(2), adminVersion)
+
+	// Token biasa tidak punya actor, claim act yang rusak ditolak
+	_, _, ok, err = TokenActor(&jwt.MapClaims{"sub": "42"})
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	_, _, ok, err = TokenActor(&jwt.MapClaims{"act": "1"})
+	assert.True(t, ok)
+	assert.NotNil(t, err)
+}
+
+// TestCanImpersonate tests that only admins can impersonate, and never themselves or another admin
+func TestCanImpersonate(t *testing.T) {
+	admin := models.User{Model: gorm.Model{ID: 1}, Role: RoleAdmin}
+	otherAdmin := models.User{Model: gorm.Model{ID: 2}, Role: RoleAdmin}
+	user := models.User{Model: gorm.Model{ID: 3}, Role: RoleUser}
+
+	assert.True(t, CanImpersonate(admin, user))
+	assert.False(t, CanImpersonate(admin, admin))
+	assert.False(t, CanImpersonate(admin, otherAdmin))
+	assert.False(t, CanImpersonate(user, admin))
+}
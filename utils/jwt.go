@@ -3,9 +3,11 @@ package utils
 import (
 	"errors"
 	"final/config"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // TokenDetails struktur untuk menyimpan informasi token
@@ -18,37 +20,55 @@ type TokenDetails struct {
 	RtExpires    int64
 }
 
+// TokenSubject data pemilik token yang disematkan ke dalam claims
+// User dikenali dari ID (claim sub) yang tidak berubah walaupun username diganti
+type TokenSubject struct {
+	UserID       uint
+	Role         string
+	TokenVersion uint   // harus sama dengan users.token_version, dinaikkan untuk mencabut semua token user
+	FamilyID     string // ID token family untuk rotasi refresh token
+}
+
 // GenerateJWT membuat token JWT access + refresh
-func GenerateJWT(username string) (*TokenDetails, error) {
+func GenerateJWT(subject TokenSubject) (*TokenDetails, error) {
 	td := &TokenDetails{}
 	
 	// Set waktu kedaluwarsa
 	td.AtExpires = time.Now().Add(time.Hour * time.Duration(config.JWTExpiryTime())).Unix()
 	td.RtExpires = time.Now().Add(time.Hour * time.Duration(config.JWTRefreshExpiryTime())).Unix()
 
+	// ID unik (jti) untuk setiap token agar bisa dicabut satu per satu
+	td.AccessUUID = uuid.NewString()
+	td.RefreshUUID = uuid.NewString()
+
 	// Access token
 	accessClaims := jwt.MapClaims{
-		"username": username,
-		"exp":      td.AtExpires,
-		"type":     "access",
+		"sub":  strconv.FormatUint(uint64(subject.UserID), 10),
+		"role": subject.Role,
+		"ver":  subject.TokenVersion,
+		"exp":  td.AtExpires,
+		"jti":  td.AccessUUID,
+		"sid":  subject.FamilyID,
+		"type": "access",
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	var err error
-	td.AccessToken, err = accessToken.SignedString(config.GetJWTSecret())
+	td.AccessToken, err = GetKeyring().Sign(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Refresh token
 	refreshClaims := jwt.MapClaims{
-		"username": username,
-		"exp":      td.RtExpires,
-		"type":     "refresh",
+		"sub":  strconv.FormatUint(uint64(subject.UserID), 10),
+		"ver":  subject.TokenVersion,
+		"exp":  td.RtExpires,
+		"jti":  td.RefreshUUID,
+		"fam":  subject.FamilyID,
+		"type": "refresh",
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	td.RefreshToken, err = refreshToken.SignedString(config.GetJWTSecret())
+	td.RefreshToken, err = GetKeyring().Sign(refreshClaims)
 	if err != nil {
 		return nil, err
 	}
@@ -58,14 +78,8 @@ func GenerateJWT(username string) (*TokenDetails, error) {
 
 // ParseJWT memverifikasi dan membaca token JWT
 func ParseJWT(tokenString string) (*jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Memastikan signing method adalah HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return config.GetJWTSecret(), nil
-	})
-
+	// Kunci verifikasi dipilih berdasarkan header kid (lihat Keyring.Keyfunc)
+	token, err := jwt.Parse(tokenString, GetKeyring().Keyfunc)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +91,23 @@ func ParseJWT(tokenString string) (*jwt.MapClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// TokenUserID membaca user ID dari claim sub
+// Token lama yang hanya membawa username ditolak sehingga user harus login ulang
+func TokenUserID(claims *jwt.MapClaims) (uint, error) {
+	sub, _ := (*claims)["sub"].(string)
+	userID, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, errors.New("invalid subject")
+	}
+	return uint(userID), nil
+}
+
+// TokenVersion membaca claim ver, token tanpa ver dianggap versi 0
+func TokenVersion(claims *jwt.MapClaims) uint {
+	version, _ := (*claims)["ver"].(float64)
+	return uint(version)
+}
+
 // ParseRefreshToken khusus untuk memvalidasi refresh token
 func ParseRefreshToken(tokenString string) (*jwt.MapClaims, error) {
 	claims, err := ParseJWT(tokenString)
@@ -88,6 +119,85 @@ func ParseRefreshToken(tokenString string) (*jwt.MapClaims, error) {
 	if tokenType, ok := (*claims)["type"].(string); !ok || tokenType != "refresh" {
 		return nil, errors.New("not a refresh token")
 	}
+
+	// Refresh token wajib memiliki jti dan family agar bisa dirotasi
+	if jti, ok := (*claims)["jti"].(string); !ok || jti == "" {
+		return nil, errors.New("refresh token has no jti")
+	}
+	if family, ok := (*claims)["fam"].(string); !ok || family == "" {
+		return nil, errors.New("refresh token has no family")
+	}
 	
 	return claims, nil
 }
+
+// GenerateEmailVerificationToken membuat token bertanda tangan untuk link verifikasi email
+// Email ikut disematkan sehingga link otomatis tidak berlaku jika email diganti
+func GenerateEmailVerificationToken(userID uint, email string) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":   strconv.FormatUint(uint64(userID), 10),
+		"email": email,
+		"exp":   time.Now().Add(time.Hour * time.Duration(config.EmailVerificationExpiryTime())).Unix(),
+		"type":  "email_verification",
+	}
+
+	return GetKeyring().Sign(claims)
+}
+
+// ParseEmailVerificationToken memvalidasi token verifikasi email dan mengembalikan user ID dan email
+func ParseEmailVerificationToken(tokenString string) (uint, string, error) {
+	claims, err := ParseJWT(tokenString)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if tokenType, ok := (*claims)["type"].(string); !ok || tokenType != "email_verification" {
+		return 0, "", errors.New("not an email verification token")
+	}
+
+	sub, _ := (*claims)["sub"].(string)
+	userID, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil {
+		return 0, "", errors.New("invalid subject")
+	}
+
+	email, ok := (*claims)["email"].(string)
+	if !ok || email == "" {
+		return 0, "", errors.New("invalid email")
+	}
+
+	return uint(userID), email, nil
+}
+
+// GenerateMFAPendingToken membuat token berumur pendek setelah password benar
+// tetapi user masih harus memasukkan kode 2FA
+func GenerateMFAPendingToken(userID uint) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  strconv.FormatUint(uint64(userID), 10),
+		"exp":  time.Now().Add(time.Minute * time.Duration(config.MFAPendingExpiryTime())).Unix(),
+		"jti":  uuid.NewString(),
+		"type": "mfa_pending",
+	}
+
+	return GetKeyring().Sign(claims)
+}
+
+// ParseMFAPendingToken memvalidasi token mfa_pending dan mengembalikan claims beserta user ID
+func ParseMFAPendingToken(tokenString string) (*jwt.MapClaims, uint, error) {
+	claims, err := ParseJWT(tokenString)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if tokenType, ok := (*claims)["type"].(string); !ok || tokenType != "mfa_pending" {
+		return nil, 0, errors.New("not an mfa_pending token")
+	}
+
+	sub, _ := (*claims)["sub"].(string)
+	userID, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("invalid subject")
+	}
+
+	return claims, uint(userID), nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateJWTClaims tests that tokens identify the user by ID and carry the token version
+func TestGenerateJWTClaims(t *testing.T) {
+	tokens, err := GenerateJWT(TokenSubject{UserID: 42, Role: RoleAdmin, TokenVersion: 3, FamilyID: "family-1"})
+	assert.Nil(t, err)
+
+	access, err := ParseJWT(tokens.AccessToken)
+	assert.Nil(t, err)
+	userID, err := TokenUserID(access)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(42), userID)
+	assert.Equal(t, uint(3), TokenVersion(access))
+	assert.Equal(t, RoleAdmin, (*access)["role"])
+	assert.Nil(t, (*access)["username"])
+
+	refresh, err := ParseRefreshToken(tokens.RefreshToken)
+	assert.Nil(t, err)
+	userID, _ = TokenUserID(refresh)
+	assert.Equal(t, uint(42), userID)
+	assert.Equal(t, uint(3), TokenVersion(refresh))
+
+	// Token tanpa sub ditolak
+	_, err = TokenUserID(&jwt.MapClaims{"username": "alice"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,373 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"final/config"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// legacyKID adalah kid untuk secret HMAC, juga dipakai untuk token lama yang tidak memiliki header kid
+const legacyKID = "hs256"
+
+// SigningKey adalah satu kunci di dalam keyring
+type SigningKey struct {
+	KID    string
+	Method jwt.SigningMethod
+	// signKey nil berarti kunci hanya untuk verifikasi (kunci lama yang sudah dirotasi)
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign mengecek apakah kunci memiliki bagian privat
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// NewHMACKey membuat kunci HS256 dari secret
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{KID: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey membuat kunci RS256, pub wajib dan priv boleh nil untuk kunci verifikasi saja
+func NewRSAKey(kid string, priv *rsa.PrivateKey, pub *rsa.PublicKey) *SigningKey {
+	key := &SigningKey{KID: kid, Method: jwt.SigningMethodRS256, verifyKey: pub}
+	if priv != nil {
+		key.signKey = priv
+	}
+	return key
+}
+
+// NewEd25519Key membuat kunci EdDSA, pub wajib dan priv boleh nil untuk kunci verifikasi saja
+func NewEd25519Key(kid string, priv ed25519.PrivateKey, pub ed25519.PublicKey) *SigningKey {
+	key := &SigningKey{KID: kid, Method: jwt.SigningMethodEdDSA, verifyKey: pub}
+	if priv != nil {
+		key.signKey = priv
+	}
+	return key
+}
+
+// Keyring menyimpan beberapa kunci berdasarkan kid
+// Token baru ditandatangani dengan kunci aktif, token lama tetap bisa diverifikasi
+// selama kuncinya masih ada di keyring sehingga rotasi tidak memutus sesi yang berjalan
+type Keyring struct {
+	mutex     sync.RWMutex
+	keys      map[string]*SigningKey
+	activeKID string
+	// legacyFallback memetakan token tanpa header kid ke kunci legacyKID
+	legacyFallback bool
+}
+
+// NewKeyring membuat keyring kosong
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*SigningKey)}
+}
+
+// Add menambahkan kunci ke keyring
+func (k *Keyring) Add(key *SigningKey) {
+	k.mutex.Lock()
+	k.keys[key.KID] = key
+	k.mutex.Unlock()
+}
+
+// SetActive memilih kunci untuk menandatangani token baru
+func (k *Keyring) SetActive(kid string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return fmt.Errorf("kunci %q tidak ada di keyring", kid)
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("kunci %q tidak memiliki kunci privat", kid)
+	}
+	k.activeKID = kid
+	return nil
+}
+
+// Rotate menambahkan kunci baru dan menjadikannya aktif, kunci lama tetap untuk verifikasi
+func (k *Keyring) Rotate(key *SigningKey) error {
+	k.Add(key)
+	return k.SetActive(key.KID)
+}
+
+// Remove menghapus kunci, token yang ditandatangani kunci ini tidak lagi valid
+func (k *Keyring) Remove(kid string) {
+	k.mutex.Lock()
+	delete(k.keys, kid)
+	if k.activeKID == kid {
+		k.activeKID = ""
+	}
+	k.mutex.Unlock()
+}
+
+// Active mengembalikan kunci yang dipakai untuk menandatangani token
+func (k *Keyring) Active() (*SigningKey, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[k.activeKID]
+	if !ok {
+		return nil, errors.New("keyring tidak memiliki kunci aktif")
+	}
+	return key, nil
+}
+
+// Lookup mencari kunci berdasarkan kid
+func (k *Keyring) Lookup(kid string) (*SigningKey, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Sign menandatangani claims dengan kunci aktif dan menambahkan header kid
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key, err := k.Active()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc dipakai jwt.Parse untuk memilih kunci verifikasi berdasarkan kid
+// Algoritma token harus sama dengan algoritma kunci untuk mencegah serangan algorithm confusion
+func (k *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		k.mutex.RLock()
+		fallback := k.legacyFallback
+		k.mutex.RUnlock()
+		if !fallback {
+			return nil, errors.New("token has no kid")
+		}
+		kid = legacyKID
+	}
+
+	key, ok := k.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// JWK adalah representasi JSON Web Key untuk kunci publik
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS mengembalikan semua kunci publik (termasuk kunci lama) dalam format JSON Web Key Set
+// Kunci HMAC tidak pernah dipublikasikan
+func (k *Keyring) JWKS() map[string][]JWK {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	keys := make([]JWK, 0, len(k.keys))
+	for _, key := range k.keys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				KTY: "RSA",
+				KID: key.KID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				KTY: "OKP",
+				KID: key.KID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KID < keys[j].KID })
+	return map[string][]JWK{"keys": keys}
+}
+
+// Keyring yang digunakan aplikasi
+var (
+	keyring      *Keyring
+	keyringMutex sync.RWMutex
+)
+
+// SetKeyring mengganti keyring yang digunakan untuk menandatangani dan memverifikasi token
+func SetKeyring(k *Keyring) {
+	keyringMutex.Lock()
+	keyring = k
+	keyringMutex.Unlock()
+}
+
+// GetKeyring mengembalikan keyring aktif
+// Jika belum diatur, dipakai keyring HS256 dari config.GetJWTSecret()
+func GetKeyring() *Keyring {
+	keyringMutex.RLock()
+	k := keyring
+	keyringMutex.RUnlock()
+	if k != nil {
+		return k
+	}
+
+	keyringMutex.Lock()
+	defer keyringMutex.Unlock()
+	if keyring == nil {
+		keyring = NewKeyring()
+		keyring.Add(NewHMACKey(legacyKID, config.GetJWTSecret()))
+		keyring.activeKID = legacyKID
+		keyring.legacyFallback = true
+	}
+	return keyring
+}
+
+// LoadKeyringFromConfig membuat keyring sesuai JWT_ALG, JWT_KEYS_DIR dan JWT_ACTIVE_KID
+func LoadKeyringFromConfig() (*Keyring, error) {
+	k := NewKeyring()
+
+	alg := config.JWTAlgorithm()
+	if alg == "HS256" {
+		k.Add(NewHMACKey(legacyKID, config.GetJWTSecret()))
+		k.activeKID = legacyKID
+		k.legacyFallback = true
+		return k, nil
+	}
+	if alg != "RS256" && alg != "EdDSA" {
+		return nil, fmt.Errorf("JWT_ALG tidak didukung: %s", alg)
+	}
+
+	// Secret HMAC hanya dipertahankan jika diminta (JWT_ACCEPT_LEGACY_HS256) agar token HS256
+	// yang sudah terbit tetap valid selama migrasi. Tanpa itu, pemegang JWT_SECRET tidak bisa
+	// lagi membuat token yang diterima
+	if config.JWTAcceptLegacyHS256() {
+		log.Printf("Warning: token HS256 lama masih diterima, matikan JWT_ACCEPT_LEGACY_HS256 setelah migrasi selesai")
+		k.Add(NewHMACKey(legacyKID, config.GetJWTSecret()))
+		k.legacyFallback = true
+	}
+
+	dir := config.JWTKeysDir()
+	if dir == "" {
+		if !config.IsDevelopment() {
+			return nil, errors.New("JWT_KEYS_DIR wajib diatur untuk " + alg + " di luar mode development")
+		}
+		// Development: buat kunci sementara yang hilang saat restart
+		key, err := generateKey("dev-"+strings.ToLower(alg), alg)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Warning: JWT_KEYS_DIR tidak diatur, memakai kunci %s sementara", alg)
+		return k, k.Rotate(key)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadPEMKey(kid, path)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca kunci %s: %w", path, err)
+		}
+		k.Add(key)
+	}
+
+	activeKID := config.JWTActiveKID()
+	if activeKID == "" {
+		return nil, errors.New("JWT_ACTIVE_KID wajib diatur untuk " + alg)
+	}
+	if key, ok := k.Lookup(activeKID); !ok || key.Method.Alg() != alg {
+		return nil, fmt.Errorf("kunci aktif %q tidak ditemukan atau bukan %s", activeKID, alg)
+	}
+	return k, k.SetActive(activeKID)
+}
+
+// generateKey membuat pasangan kunci baru untuk algoritma tertentu
+func generateKey(kid, alg string) (*SigningKey, error) {
+	switch alg {
+	case "RS256":
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(kid, priv, &priv.PublicKey), nil
+	case "EdDSA":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewEd25519Key(kid, priv, pub), nil
+	}
+	return nil, fmt.Errorf("algoritma tidak didukung: %s", alg)
+}
+
+// loadPEMKey membaca kunci privat (PKCS#8/PKCS#1) atau kunci publik (PKIX) dari file PEM
+func loadPEMKey(kid, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("bukan file PEM")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("tipe PEM tidak didukung: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return NewRSAKey(kid, key, &key.PublicKey), nil
+	case *rsa.PublicKey:
+		return NewRSAKey(kid, nil, key), nil
+	case ed25519.PrivateKey:
+		return NewEd25519Key(kid, key, key.Public().(ed25519.PublicKey)), nil
+	case ed25519.PublicKey:
+		return NewEd25519Key(kid, nil, key), nil
+	}
+	return nil, fmt.Errorf("jenis kunci tidak didukung: %T", parsed)
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// withKeyring swaps the package keyring for the duration of a test
+func withKeyring(t *testing.T, k *Keyring) {
+	previous := GetKeyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+}
+
+// TestKeyringRotation tests that tokens signed with a rotated-out key stay valid
+func TestKeyringRotation(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		k := NewKeyring()
+		first, err := generateKey("key-1", alg)
+		assert.Nil(t, err)
+		assert.Nil(t, k.Rotate(first))
+		withKeyring(t, k)
+
+		oldTokens, err := GenerateJWT(TokenSubject{UserID: 1, FamilyID: "family-1"})
+		assert.Nil(t, err)
+
+		second, err := generateKey("key-2", alg)
+		assert.Nil(t, err)
+		assert.Nil(t, k.Rotate(second))
+
+		newTokens, err := GenerateJWT(TokenSubject{UserID: 1, FamilyID: "family-1"})
+		assert.Nil(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(newTokens.AccessToken, jwt.MapClaims{})
+		assert.Nil(t, err)
+		assert.Equal(t, "key-2", parsed.Header["kid"])
+		assert.Equal(t, alg, parsed.Method.Alg())
+
+		// Both the old and the new token verify
+		_, err = ParseJWT(oldTokens.AccessToken)
+		assert.Nil(t, err, alg)
+		_, err = ParseJWT(newTokens.AccessToken)
+		assert.Nil(t, err, alg)
+
+		// Removing the old key invalidates its tokens
+		k.Remove("key-1")
+		_, err = ParseJWT(oldTokens.AccessToken)
+		assert.NotNil(t, err, alg)
+	}
+}
+
+// TestKeyringRejectsAlgorithmConfusion tests that an HS256 token cannot claim an RSA kid
+func TestKeyringRejectsAlgorithmConfusion(t *testing.T) {
+	k := NewKeyring()
+	rsaKey, _ := generateKey("rsa-1", "RS256")
+	assert.Nil(t, k.Rotate(rsaKey))
+	withKeyring(t, k)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"username": "admin", "type": "access"})
+	forged.Header["kid"] = "rsa-1"
+	tokenString, _ := forged.SignedString([]byte("guess"))
+
+	_, err := ParseJWT(tokenString)
+	assert.NotNil(t, err)
+}
+
+// TestJWKS tests that only public keys are published
+func TestJWKS(t *testing.T) {
+	k := NewKeyring()
+	k.Add(NewHMACKey(legacyKID, []byte("secret")))
+	rsaKey, _ := generateKey("rsa-1", "RS256")
+	edKey, _ := generateKey("ed-1", "EdDSA")
+	k.Add(rsaKey)
+	k.Add(edKey)
+
+	keys := k.JWKS()["keys"]
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "ed-1", keys[0].KID)
+	assert.Equal(t, "OKP", keys[0].KTY)
+	assert.Equal(t, "rsa-1", keys[1].KID)
+	assert.Equal(t, "AQAB", keys[1].E)
+}
+
+// TestLoadKeyringFromConfig tests loading signing and verify-only keys from a directory
+func TestLoadKeyringFromConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	active, _ := generateKey("2024-02", "EdDSA")
+	retired, _ := generateKey("2024-01", "EdDSA")
+
+	privBytes, _ := x509.MarshalPKCS8PrivateKey(active.signKey)
+	pubBytes, _ := x509.MarshalPKIXPublicKey(retired.verifyKey)
+	_ = os.WriteFile(filepath.Join(dir, "2024-02.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}), 0o600)
+	_ = os.WriteFile(filepath.Join(dir, "2024-01.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0o600)
+
+	t.Setenv("JWT_ALG", "EdDSA")
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "2024-02")
+
+	k, err := LoadKeyringFromConfig()
+	assert.Nil(t, err)
+
+	key, err := k.Active()
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-02", key.KID)
+
+	old, ok := k.Lookup("2024-01")
+	assert.True(t, ok)
+	assert.False(t, old.CanSign())
+
+	// A verify-only key cannot become the active key
+	t.Setenv("JWT_ACTIVE_KID", "2024-01")
+	_, err = LoadKeyringFromConfig()
+	assert.NotNil(t, err)
+}
+
+// TestLoadKeyringLegacyHS256 tests that JWT_SECRET only keeps working after a migration when explicitly allowed
+func TestLoadKeyringLegacyHS256(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("JWT_ALG", "EdDSA")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_SECRET", "old-secret")
+
+	withoutKID, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"}).SignedString([]byte("old-secret"))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	token.Header["kid"] = legacyKID
+	withKID, _ := token.SignedString([]byte("old-secret"))
+
+	// Default: the HMAC key is gone and tokens without kid are rejected
+	t.Setenv("JWT_ACCEPT_LEGACY_HS256", "")
+	k, err := LoadKeyringFromConfig()
+	assert.Nil(t, err)
+	_, ok := k.Lookup(legacyKID)
+	assert.False(t, ok)
+	_, err = jwt.Parse(withKID, k.Keyfunc)
+	assert.NotNil(t, err)
+	_, err = jwt.Parse(withoutKID, k.Keyfunc)
+	assert.NotNil(t, err)
+
+	// Opt-in: old HS256 tokens stay valid during the migration
+	t.Setenv("JWT_ACCEPT_LEGACY_HS256", "true")
+	k, err = LoadKeyringFromConfig()
+	assert.Nil(t, err)
+	_, err = jwt.Parse(withKID, k.Keyfunc)
+	assert.Nil(t, err)
+	_, err = jwt.Parse(withoutKID, k.Keyfunc)
+	assert.Nil(t, err)
+
+	// New tokens are still signed with the asymmetric key
+	active, err := k.Active()
+	assert.Nil(t, err)
+	assert.Equal(t, "EdDSA", active.Method.Alg())
+}
+
+// TestLoadKeyringRequiresExplicitDevelopment tests that a missing APP_ENV is treated as production
+func TestLoadKeyringRequiresExplicitDevelopment(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_ALG", "EdDSA")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_ACCEPT_LEGACY_HS256", "")
+
+	_, err := LoadKeyringFromConfig()
+	assert.NotNil(t, err)
+
+	t.Setenv("APP_ENV", "development")
+	_, err = LoadKeyringFromConfig()
+	assert.Nil(t, err)
+}
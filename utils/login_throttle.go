@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"final/config"
+	"final/models"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt adalah status login gagal untuk satu kunci
+type LoginAttempt struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// LoginAttemptStore menyimpan penghitung login gagal, bisa dibagi antar instance
+type LoginAttemptStore interface {
+	// Get mengembalikan status kunci, kunci yang belum ada bernilai nol
+	Get(key string) (LoginAttempt, error)
+	// RecordFailure menambah penghitung secara atomik, penghitung dimulai ulang
+	// jika kegagalan terakhir lebih lama dari window
+	RecordFailure(key string, window time.Duration) (LoginAttempt, error)
+	// Reset menghapus penghitung kunci
+	Reset(key string) error
+	// Prune menghapus penghitung yang kegagalan terakhirnya lebih lama dari window
+	Prune(window time.Duration) error
+}
+
+// MemoryLoginAttemptStore implementasi LoginAttemptStore di memori
+type MemoryLoginAttemptStore struct {
+	mutex    sync.Mutex
+	attempts map[string]LoginAttempt
+}
+
+// NewMemoryLoginAttemptStore membuat LoginAttemptStore di memori
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[string]LoginAttempt)}
+}
+
+// Get mengembalikan status kunci
+func (s *MemoryLoginAttemptStore) Get(key string) (LoginAttempt, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.attempts[key], nil
+}
+
+// RecordFailure menambah penghitung kunci
+func (s *MemoryLoginAttemptStore) RecordFailure(key string, window time.Duration) (LoginAttempt, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	attempt := s.attempts[key]
+	if now.Sub(attempt.LastFailureAt) > window {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	s.attempts[key] = attempt
+	return attempt, nil
+}
+
+// Reset menghapus penghitung kunci
+func (s *MemoryLoginAttemptStore) Reset(key string) error {
+	s.mutex.Lock()
+	delete(s.attempts, key)
+	s.mutex.Unlock()
+	return nil
+}
+
+// Prune menghapus kunci yang sudah lewat window agar map tidak tumbuh terus
+func (s *MemoryLoginAttemptStore) Prune(window time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, attempt := range s.attempts {
+		if now.Sub(attempt.LastFailureAt) > window {
+			delete(s.attempts, key)
+		}
+	}
+	return nil
+}
+
+// DBLoginAttemptStore implementasi LoginAttemptStore di PostgreSQL
+type DBLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+// NewDBLoginAttemptStore membuat LoginAttemptStore yang disimpan di database
+func NewDBLoginAttemptStore(db *gorm.DB) *DBLoginAttemptStore {
+	return &DBLoginAttemptStore{db: db}
+}
+
+// Get mengembalikan status kunci dari tabel login_attempts
+func (s *DBLoginAttemptStore) Get(key string) (LoginAttempt, error) {
+	var row models.LoginAttempt
+	if err := s.db.First(&row, "key = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return LoginAttempt{}, nil
+		}
+		return LoginAttempt{}, err
+	}
+	return LoginAttempt{Failures: row.Failures, LastFailureAt: row.LastFailureAt}, nil
+}
+
+// RecordFailure menambah penghitung dengan satu query upsert agar aman antar instance
+func (s *DBLoginAttemptStore) RecordFailure(key string, window time.Duration) (LoginAttempt, error) {
+	now := time.Now()
+	var row models.LoginAttempt
+	err := s.db.Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at`,
+		key, now, now.Add(-window)).Scan(&row).Error
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	return LoginAttempt{Failures: row.Failures, LastFailureAt: row.LastFailureAt}, nil
+}
+
+// Reset menghapus baris kunci
+func (s *DBLoginAttemptStore) Reset(key string) error {
+	return s.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// Prune menghapus baris yang sudah lewat window
+func (s *DBLoginAttemptStore) Prune(window time.Duration) error {
+	return s.db.Where("last_failure_at < ?", time.Now().Add(-window)).Delete(&models.LoginAttempt{}).Error
+}
+
+// Store yang digunakan aplikasi, default di memori
+var (
+	loginAttemptStore LoginAttemptStore = NewMemoryLoginAttemptStore()
+	loginAttemptMutex sync.RWMutex
+)
+
+// SetLoginAttemptStore mengganti store penghitung login gagal
+func SetLoginAttemptStore(store LoginAttemptStore) {
+	loginAttemptMutex.Lock()
+	loginAttemptStore = store
+	loginAttemptMutex.Unlock()
+}
+
+// GetLoginAttemptStore mengembalikan store penghitung login gagal yang aktif
+func GetLoginAttemptStore() LoginAttemptStore {
+	loginAttemptMutex.RLock()
+	defer loginAttemptMutex.RUnlock()
+	return loginAttemptStore
+}
+
+// StartLoginAttemptPruner menjalankan goroutine untuk membersihkan penghitung login gagal
+// yang sudah lewat window secara berkala sampai ctx dibatalkan
+func StartLoginAttemptPruner(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := GetLoginAttemptStore().Prune(config.LoginFailureWindow()); err != nil {
+					log.Printf("Gagal membersihkan penghitung login gagal: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// LockoutDelay menghitung berapa lama kunci harus menunggu setelah sejumlah kegagalan
+func LockoutDelay(policy config.LockoutPolicy, failures int) time.Duration {
+	if failures >= policy.MaxFailures {
+		return policy.Lockout
+	}
+	if failures <= policy.FreeAttempts {
+		return 0
+	}
+
+	// Jeda berlipat ganda: 1x, 2x, 4x, ... tetapi tidak lebih dari lama lockout
+	delay := policy.BaseDelay
+	for i := policy.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= policy.Lockout {
+			return policy.Lockout
+		}
+	}
+	return delay
+}
+
+// retryAfter menghitung sisa waktu tunggu untuk status login tertentu
+func retryAfter(policy config.LockoutPolicy, attempt LoginAttempt, now time.Time) time.Duration {
+	delay := LockoutDelay(policy, attempt.Failures)
+	if delay == 0 {
+		return 0
+	}
+	wait := attempt.LastFailureAt.Add(delay).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// loginKeys mengembalikan kunci penghitung beserta aturannya untuk username dan IP
+func loginKeys(username, ip string) map[string]config.LockoutPolicy {
+	return map[string]config.LockoutPolicy{
+		"user:" + username: config.LoginUserLockout(),
+		"ip:" + ip:         config.LoginIPLockout(),
+	}
+}
+
+// LoginRetryAfter mengecek apakah username atau IP sedang ditunda/terkunci
+// dan mengembalikan sisa waktu tunggu terlama (0 berarti boleh mencoba)
+func LoginRetryAfter(username, ip string) (time.Duration, error) {
+	store := GetLoginAttemptStore()
+	now := time.Now()
+
+	var longest time.Duration
+	for key, policy := range loginKeys(username, ip) {
+		attempt, err := store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if wait := retryAfter(policy, attempt, now); wait > longest {
+			longest = wait
+		}
+	}
+	return longest, nil
+}
+
+// RecordLoginFailure mencatat login gagal untuk username dan IP
+// dan mengembalikan waktu tunggu sebelum percobaan berikutnya
+func RecordLoginFailure(username, ip string) (time.Duration, error) {
+	store := GetLoginAttemptStore()
+	now := time.Now()
+
+	var longest time.Duration
+	for key, policy := range loginKeys(username, ip) {
+		attempt, err := store.RecordFailure(key, config.LoginFailureWindow())
+		if err != nil {
+			return 0, err
+		}
+		if wait := retryAfter(policy, attempt, now); wait > longest {
+			longest = wait
+		}
+	}
+	return longest, nil
+}
+
+// ResetLoginFailures menghapus penghitung username, dipanggil setelah login berhasil
+// atau saat admin membuka kunci akun. Penghitung IP sengaja tidak direset agar
+// penyerang tidak bisa mereset IP-nya dengan login ke akun miliknya sendiri
+func ResetLoginFailures(username string) error {
+	return GetLoginAttemptStore().Reset("user:" + username)
+}
+
+// ResetIPLoginFailures menghapus penghitung sebuah IP (untuk admin)
+func ResetIPLoginFailures(ip string) error {
+	return GetLoginAttemptStore().Reset("ip:" + ip)
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"final/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLockoutDelay tests free attempts, exponential backoff and the lockout cap
+func TestLockoutDelay(t *testing.T) {
+	policy := config.LockoutPolicy{FreeAttempts: 3, MaxFailures: 10, BaseDelay: time.Second, Lockout: 15 * time.Minute}
+
+	assert.Equal(t, time.Duration(0), LockoutDelay(policy, 0))
+	assert.Equal(t, time.Duration(0), LockoutDelay(policy, 3))
+	assert.Equal(t, time.Second, LockoutDelay(policy, 4))
+	assert.Equal(t, 2*time.Second, LockoutDelay(policy, 5))
+	assert.Equal(t, 4*time.Second, LockoutDelay(policy, 6))
+	assert.Equal(t, 15*time.Minute, LockoutDelay(policy, 10))
+
+	// Jeda tidak pernah melebihi lama lockout
+	short := config.LockoutPolicy{FreeAttempts: 0, MaxFailures: 50, BaseDelay: time.Minute, Lockout: 5 * time.Minute}
+	assert.Equal(t, 5*time.Minute, LockoutDelay(short, 20))
+}
+
+// TestMemoryLoginAttemptStore tests counting, window expiry and reset
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+
+	attempt, err := store.RecordFailure("user:alice", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	attempt, _ = store.RecordFailure("user:alice", time.Hour)
+	assert.Equal(t, 2, attempt.Failures)
+
+	// Kegagalan yang lebih lama dari window tidak dihitung lagi
+	attempt, _ = store.RecordFailure("user:alice", 0)
+	assert.Equal(t, 1, attempt.Failures)
+
+	assert.Nil(t, store.Reset("user:alice"))
+	attempt, _ = store.Get("user:alice")
+	assert.Equal(t, 0, attempt.Failures)
+
+	// Prune hanya menghapus kunci yang sudah lewat window
+	store.RecordFailure("user:alice", time.Hour)
+	store.RecordFailure("ip:10.0.0.1", time.Hour)
+	store.attempts["ip:10.0.0.1"] = LoginAttempt{Failures: 5, LastFailureAt: time.Now().Add(-2 * time.Hour)}
+	assert.Nil(t, store.Prune(time.Hour))
+	attempt, _ = store.Get("user:alice")
+	assert.Equal(t, 1, attempt.Failures)
+	attempt, _ = store.Get("ip:10.0.0.1")
+	assert.Equal(t, 0, attempt.Failures)
+}
+
+// TestLoginLockout tests that repeated failures lock the username and that a reset unlocks it
+func TestLoginLockout(t *testing.T) {
+	SetLoginAttemptStore(NewMemoryLoginAttemptStore())
+	policy := config.LoginUserLockout()
+
+	for i := 0; i < policy.FreeAttempts; i++ {
+		wait, err := RecordLoginFailure("alice", "198.51.100.1")
+		assert.Nil(t, err)
+		assert.Equal(t, time.Duration(0), wait)
+	}
+
+	wait, _ := LoginRetryAfter("alice", "198.51.100.1")
+	assert.Equal(t, time.Duration(0), wait)
+
+	// Kegagalan berikutnya mulai ditunda
+	wait, _ = RecordLoginFailure("alice", "198.51.100.1")
+	assert.True(t, wait > 0)
+	wait, _ = LoginRetryAfter("alice", "198.51.100.2")
+	assert.True(t, wait > 0, "username tetap ditunda dari IP lain")
+
+	for i := policy.FreeAttempts + 1; i < policy.MaxFailures; i++ {
+		RecordLoginFailure("alice", "198.51.100.1")
+	}
+	wait, _ = LoginRetryAfter("alice", "198.51.100.3")
+	assert.True(t, wait > policy.Lockout-time.Minute)
+
+	// User lain dari IP yang sama belum terkena batas IP
+	wait, _ = LoginRetryAfter("bob", "198.51.100.1")
+	assert.Equal(t, time.Duration(0), wait)
+
+	assert.Nil(t, ResetLoginFailures("alice"))
+	wait, _ = LoginRetryAfter("alice", "198.51.100.3")
+	assert.Equal(t, time.Duration(0), wait)
+}
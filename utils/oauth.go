@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"final/config"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrOAuthProviderUnavailable penyedia tidak bisa dihubungi atau membalas dengan format yang salah
+	ErrOAuthProviderUnavailable = errors.New("penyedia login tidak dapat dihubungi")
+	// ErrOAuthExchangeFailed code ditolak penyedia (kedaluwarsa, sudah dipakai, atau PKCE salah)
+	ErrOAuthExchangeFailed = errors.New("authorization code ditolak penyedia")
+	// ErrOAuthInvalidIDToken ID token tidak valid (tanda tangan, issuer, audience, exp atau nonce)
+	ErrOAuthInvalidIDToken = errors.New("ID token tidak valid")
+)
+
+// ExternalIdentity adalah user yang sudah diverifikasi oleh penyedia login luar
+type ExternalIdentity struct {
+	Provider          string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// OAuthProvider adalah penyedia login dengan authorization code flow + PKCE
+type OAuthProvider interface {
+	// Name adalah nama penyedia di URL, contoh "google"
+	Name() string
+	// AuthCodeURL adalah URL halaman login penyedia tempat user diarahkan
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange menukar authorization code dengan identitas user yang sudah diverifikasi
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
+
+// NewPKCEVerifier membuat code verifier PKCE acak (43 karakter base64url)
+func NewPKCEVerifier() (string, error) {
+	return GenerateSecureToken(32)
+}
+
+// PKCEChallenge menghitung code challenge S256 dari code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// oidcDiscovery adalah bagian dokumen /.well-known/openid-configuration yang dipakai
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider implementasi OAuthProvider untuk penyedia OpenID Connect
+// Endpoint ditemukan lewat discovery saat pertama dipakai, kunci JWKS di-cache
+// dan diambil ulang jika ID token memakai kid yang belum dikenal (rotasi kunci penyedia)
+type OIDCProvider struct {
+	config config.OAuthProviderConfig
+	client *http.Client
+
+	mutex     sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*SigningKey
+	// keysFetchedAt membatasi pengambilan ulang JWKS karena kid tidak dikenal
+	keysFetchedAt time.Time
+}
+
+// NewOIDCProvider membuat penyedia OIDC, client nil berarti http.Client dengan timeout 10 detik
+func NewOIDCProvider(cfg config.OAuthProviderConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{config: cfg, client: client}
+}
+
+// Name mengembalikan nama penyedia
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL membuat URL authorize dengan state, nonce dan code challenge S256
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", ErrOAuthProviderUnavailable
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange menukar code di token endpoint lalu memverifikasi ID token yang dikembalikan
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthProviderUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrOAuthExchangeFailed, body.Error)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: respons tidak berisi id_token", ErrOAuthInvalidIDToken)
+	}
+
+	return p.verifyIDToken(ctx, discovery, body.IDToken, nonce)
+}
+
+// verifyIDToken memeriksa tanda tangan, iss, aud, exp dan nonce ID token
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, idToken, nonce string) (*ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.lookupKey(ctx, discovery, kid)
+		if err != nil {
+			return nil, err
+		}
+		// Algoritma token harus sama dengan algoritma kunci, sama seperti Keyring.Keyfunc
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.verifyKey, nil
+	},
+		jwt.WithValidMethods([]string{"RS256", "EdDSA"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthInvalidIDToken, err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce tidak cocok", ErrOAuthInvalidIDToken)
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: claim sub kosong", ErrOAuthInvalidIDToken)
+	}
+
+	identity := &ExternalIdentity{Provider: p.config.Name, Subject: subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	// Beberapa penyedia mengirim email_verified sebagai string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	return identity, nil
+}
+
+// discover mengambil dokumen discovery sekali lalu menyimpannya
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &discovery); err != nil {
+		return nil, err
+	}
+	// Issuer di dokumen wajib sama dengan issuer yang diatur (OIDC Discovery 4.3)
+	if discovery.Issuer != p.config.Issuer || discovery.AuthorizationEndpoint == "" ||
+		discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: dokumen discovery tidak valid", ErrOAuthProviderUnavailable)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// lookupKey mencari kunci verifikasi berdasarkan kid
+// JWKS diambil ulang paling sering sekali per menit saat kid tidak dikenal
+func (p *OIDCProvider) lookupKey(ctx context.Context, discovery *oidcDiscovery, kid string) (*SigningKey, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < time.Minute {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var set struct {
+		Keys []JWK `json:"keys"`
+	}
+	p.keysFetchedAt = time.Now()
+	if err := p.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]*SigningKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if key, err := jwk.verificationKey(); err == nil {
+			p.keys[jwk.KID] = key
+		}
+	}
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// findKey mencari kunci di cache, token tanpa kid boleh dipakai jika penyedia hanya punya satu kunci
+// Dipanggil dengan mutex terkunci
+func (p *OIDCProvider) findKey(kid string) (*SigningKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// getJSON melakukan GET dan membaca respons JSON dari penyedia
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s membalas %d", ErrOAuthProviderUnavailable, target, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthProviderUnavailable, err)
+	}
+	return nil
+}
+
+// verificationKey mengubah JWK publik RSA atau Ed25519 menjadi kunci verifikasi
+func (j JWK) verificationKey() (*SigningKey, error) {
+	switch j.KTY {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		return NewRSAKey(j.KID, nil, pub), nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("kunci OKP tidak valid")
+		}
+		return NewEd25519Key(j.KID, nil, ed25519.PublicKey(x)), nil
+	}
+	return nil, fmt.Errorf("jenis kunci tidak didukung: %s", j.KTY)
+}
+
+// Penyedia login yang terdaftar, berdasarkan nama
+var (
+	oauthProviders      = make(map[string]OAuthProvider)
+	oauthProvidersMutex sync.RWMutex
+)
+
+// SetOAuthProvider mendaftarkan atau mengganti penyedia login
+func SetOAuthProvider(provider OAuthProvider) {
+	oauthProvidersMutex.Lock()
+	oauthProviders[provider.Name()] = provider
+	oauthProvidersMutex.Unlock()
+}
+
+// RemoveOAuthProvider menghapus penyedia login
+func RemoveOAuthProvider(name string) {
+	oauthProvidersMutex.Lock()
+	delete(oauthProviders, name)
+	oauthProvidersMutex.Unlock()
+}
+
+// GetOAuthProvider mencari penyedia login berdasarkan nama
+func GetOAuthProvider(name string) (OAuthProvider, bool) {
+	oauthProvidersMutex.RLock()
+	defer oauthProvidersMutex.RUnlock()
+	provider, ok := oauthProviders[name]
+	return provider, ok
+}
+
+// OAuthProviderNames mengembalikan nama semua penyedia yang terdaftar, terurut
+func OAuthProviderNames() []string {
+	oauthProvidersMutex.RLock()
+	defer oauthProvidersMutex.RUnlock()
+	names := make([]string, 0, len(oauthProviders))
+	for name := range oauthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
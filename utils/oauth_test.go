@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"final/config"
+	"final/utils/oidctest"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestProvider starts a fake issuer and returns a provider configured against it
+func newTestProvider(t *testing.T) (*oidctest.Issuer, *OIDCProvider) {
+	issuer := oidctest.NewIssuer("client-id", "client-secret")
+	t.Cleanup(issuer.Close)
+
+	provider := NewOIDCProvider(config.OAuthProviderConfig{
+		Name:         "fake",
+		Issuer:       issuer.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://api.test/auth/fake/callback",
+		Scopes:       []string{"openid", "email"},
+	}, issuer.Client())
+	return issuer, provider
+}
+
+// authorizeCode follows the authorization URL and returns the code and state sent back to the redirect URL
+func authorizeCode(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "/auth/fake/callback", location.Path)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// Contoh dari RFC 7636 Appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewPKCEVerifier()
+	assert.Nil(t, err)
+	assert.Len(t, verifier, 43)
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	issuer, provider := newTestProvider(t)
+	issuer.SetUser(oidctest.User{Subject: "abc", Email: "jane@example.com", EmailVerified: true, PreferredUsername: "jane"})
+	ctx := context.Background()
+
+	verifier, _ := NewPKCEVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Contains(t, authURL, "code_challenge_method=S256")
+	assert.Contains(t, authURL, "scope=openid+email")
+
+	code, state := authorizeCode(t, authURL)
+	assert.Equal(t, "state-1", state)
+
+	identity, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, &ExternalIdentity{
+		Provider:          "fake",
+		Subject:           "abc",
+		Email:             "jane@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jane",
+	}, identity)
+
+	// Code sekali pakai
+	_, err = provider.Exchange(ctx, code, verifier, "nonce-1")
+	assert.True(t, errors.Is(err, ErrOAuthExchangeFailed))
+}
+
+func TestOIDCProviderRejects(t *testing.T) {
+	ctx := context.Background()
+	verifier, _ := NewPKCEVerifier()
+
+	start := func(t *testing.T, provider *OIDCProvider) string {
+		authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", PKCEChallenge(verifier))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		code, _ := authorizeCode(t, authURL)
+		return code
+	}
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		_, provider := newTestProvider(t)
+		other, _ := NewPKCEVerifier()
+		_, err := provider.Exchange(ctx, start(t, provider), other, "nonce")
+		assert.True(t, errors.Is(err, ErrOAuthExchangeFailed))
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		_, provider := newTestProvider(t)
+		_, err := provider.Exchange(ctx, start(t, provider), verifier, "other")
+		assert.True(t, errors.Is(err, ErrOAuthInvalidIDToken))
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		issuer, provider := newTestProvider(t)
+		issuer.SetAudience("another-client")
+		_, err := provider.Exchange(ctx, start(t, provider), verifier, "nonce")
+		assert.True(t, errors.Is(err, ErrOAuthInvalidIDToken))
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		issuer, provider := newTestProvider(t)
+		issuer.SetIssuer("https://evil.example.com")
+		_, err := provider.Exchange(ctx, start(t, provider), verifier, "nonce")
+		assert.True(t, errors.Is(err, ErrOAuthInvalidIDToken))
+	})
+
+	t.Run("provider down", func(t *testing.T) {
+		issuer, provider := newTestProvider(t)
+		issuer.Close()
+		_, err := provider.AuthCodeURL(ctx, "state", "nonce", "challenge")
+		assert.True(t, errors.Is(err, ErrOAuthProviderUnavailable))
+	})
+}
+
+func TestOAuthProviderRegistry(t *testing.T) {
+	_, provider := newTestProvider(t)
+	SetOAuthProvider(provider)
+	defer RemoveOAuthProvider("fake")
+
+	found, ok := GetOAuthProvider("fake")
+	assert.True(t, ok)
+	assert.Equal(t, provider, found)
+	assert.Contains(t, OAuthProviderNames(), "fake")
+
+	_, ok = GetOAuthProvider("missing")
+	assert.False(t, ok)
+}
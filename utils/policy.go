@@ -0,0 +1,40 @@
+package utils
+
+// Actor adalah user yang sedang melakukan request
+type Actor struct {
+	ID       uint
+	Username string
+	Role     string
+	// Scopes membatasi permission untuk request dengan API key, nil berarti tanpa batas
+	Scopes []Permission
+}
+
+// Can mengecek apakah actor boleh memakai permission, dari role maupun scope API key
+func (a Actor) Can(permission Permission) bool {
+	return HasPermission(a.Role, permission) && ScopesAllow(a.Scopes, permission)
+}
+
+// Policy memutuskan apakah actor boleh melakukan aksi pada resource milik ownerID
+type Policy func(actor Actor, ownerID uint) bool
+
+// OwnerOr mengizinkan pemilik resource atau role yang memiliki permission override
+func OwnerOr(override Permission) Policy {
+	return func(actor Actor, ownerID uint) bool {
+		return actor.ID == ownerID || actor.Can(override)
+	}
+}
+
+// SelfOnly hanya mengizinkan user itu sendiri, admin pun tidak dikecualikan
+func SelfOnly(actor Actor, ownerID uint) bool {
+	return actor.ID == ownerID
+}
+
+// Policy yang digunakan oleh handler
+var (
+	// CanEditPost pemilik post atau moderator boleh mengubah/menghapus post
+	CanEditPost = OwnerOr(PermPostsModerate)
+	// CanEditUser user itu sendiri atau admin boleh mengubah/menghapus data user
+	CanEditUser = OwnerOr(PermUsersManage)
+	// CanChangePassword hanya user itu sendiri yang boleh mengganti password
+	CanChangePassword Policy = SelfOnly
+)
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"final/config"
+	"final/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuotaOperation adalah nama operasi mahal yang dibatasi per hari
+type QuotaOperation string
+
+// Daftar operasi berkuota
+const (
+	OpPostCreate QuotaOperation = "posts:create"
+	OpUpload     QuotaOperation = "uploads"
+)
+
+// Quota adalah batas pemakaian untuk satu role
+type Quota struct {
+	RequestsPerMinute int
+	Burst             int
+	// Daily batas harian per operasi, operasi yang tidak ada di map tidak dibatasi
+	Daily map[QuotaOperation]int
+}
+
+// roleQuotas memetakan setiap role ke kuotanya
+var roleQuotas = map[string]Quota{
+	RoleUser: {
+		RequestsPerMinute: 60,
+		Burst:             20,
+		Daily:             map[QuotaOperation]int{OpPostCreate: 50, OpUpload: 20},
+	},
+	RolePremium: {
+		RequestsPerMinute: 300,
+		Burst:             60,
+		Daily:             map[QuotaOperation]int{OpPostCreate: 500, OpUpload: 200},
+	},
+	RoleAdmin: {
+		RequestsPerMinute: 600,
+		Burst:             120,
+		Daily:             map[QuotaOperation]int{},
+	},
+}
+
+// QuotaFor mengembalikan kuota role, role yang tidak dikenal mendapat kuota user
+func QuotaFor(role string) Quota {
+	if quota, ok := roleQuotas[role]; ok {
+		return quota
+	}
+	return roleQuotas[RoleUser]
+}
+
+// QuotaRoles mengembalikan semua role yang memiliki kuota
+func QuotaRoles() []string {
+	roles := make([]string, 0, len(roleQuotas))
+	for role := range roleQuotas {
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// RateLimitPolicy mengubah kuota per menit menjadi aturan rate limiter
+func (q Quota) RateLimitPolicy(role string) config.RateLimitPolicy {
+	return config.RateLimitPolicy{Name: "role:" + role, Rate: q.RequestsPerMinute, Period: time.Minute, Burst: q.Burst}
+}
+
+// QuotaDay mengembalikan tanggal (UTC) yang dipakai untuk menghitung kuota harian
+func QuotaDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// QuotaResetAt mengembalikan waktu kuota harian dimulai dari nol lagi
+func QuotaResetAt(now time.Time) time.Time {
+	return QuotaDay(now).AddDate(0, 0, 1)
+}
+
+// ReserveDailyQuota menambah pemakaian operasi hari ini jika masih di bawah limit
+// limit 0 berarti tanpa batas (pemakaian tetap dicatat). Penambahan dan pengecekan
+// dilakukan dalam satu query agar request paralel tidak bisa melewati limit
+func ReserveDailyQuota(db *gorm.DB, userID uint, op QuotaOperation, limit int) (used int, ok bool, err error) {
+	now := time.Now()
+	var row models.UsageRecord
+
+	query := `
+		INSERT INTO usage_records (user_id, day, operation, count, updated_at) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (user_id, day, operation) DO UPDATE SET
+			count = usage_records.count + 1,
+			updated_at = EXCLUDED.updated_at`
+	args := []interface{}{userID, QuotaDay(now), string(op), now}
+	if limit > 0 {
+		query += ` WHERE usage_records.count < ?`
+		args = append(args, limit)
+	}
+	query += ` RETURNING user_id, day, operation, count, updated_at`
+
+	result := db.Raw(query, args...).Scan(&row)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Baris tidak diubah karena limit sudah tercapai
+		return limit, false, nil
+	}
+	return row.Count, true, nil
+}
+
+// ReleaseDailyQuota mengembalikan satu pemakaian, dipakai jika operasi ternyata gagal
+func ReleaseDailyQuota(db *gorm.DB, userID uint, op QuotaOperation) error {
+	return db.Model(&models.UsageRecord{}).
+		Where("user_id = ? AND day = ? AND operation = ? AND count > 0", userID, QuotaDay(time.Now()), string(op)).
+		Update("count", gorm.Expr("count - 1")).Error
+}
+
+// DailyUsage mengembalikan pemakaian setiap operasi milik user pada hari tertentu
+func DailyUsage(db *gorm.DB, userID uint, day time.Time) (map[QuotaOperation]int, error) {
+	var rows []models.UsageRecord
+	if err := db.Where("user_id = ? AND day = ?", userID, QuotaDay(day)).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	usage := make(map[QuotaOperation]int, len(rows))
+	for _, row := range rows {
+		usage[QuotaOperation(row.Operation)] = row.Count
+	}
+	return usage, nil
+}
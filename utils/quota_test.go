@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"final/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQuotaFor tests role tiers and the fallback for unknown roles
+func TestQuotaFor(t *testing.T) {
+	assert.Equal(t, 60, QuotaFor(RoleUser).RequestsPerMinute)
+	assert.Greater(t, QuotaFor(RolePremium).RequestsPerMinute, QuotaFor(RoleUser).RequestsPerMinute)
+	assert.Greater(t, QuotaFor(RoleAdmin).RequestsPerMinute, QuotaFor(RolePremium).RequestsPerMinute)
+	assert.Equal(t, QuotaFor(RoleUser), QuotaFor("unknown"))
+
+	// Admin tidak memiliki batas harian
+	_, limited := QuotaFor(RoleAdmin).Daily[OpUpload]
+	assert.False(t, limited)
+
+	policy := QuotaFor(RoleUser).RateLimitPolicy(RoleUser)
+	assert.Equal(t, "role:user", policy.Name)
+	assert.Equal(t, time.Minute, policy.Period)
+}
+
+// TestRoleQuotasBelowGlobalLimit tests that every tier fits under the per-IP limit checked before it
+func TestRoleQuotasBelowGlobalLimit(t *testing.T) {
+	global := config.RateLimitGlobal()
+	globalPerMinute := global.Rate * int(time.Minute/global.Period)
+
+	for _, role := range QuotaRoles() {
+		quota := QuotaFor(role)
+		assert.Greater(t, globalPerMinute, quota.RequestsPerMinute, role)
+		assert.Greater(t, global.Burst, quota.Burst, role)
+	}
+}
+
+// TestRoleQuotaThroughGlobalLimit tests that premium and admin clients get their tier behind the per-IP limit
+func TestRoleQuotaThroughGlobalLimit(t *testing.T) {
+	// Klien mengirim 20 request per detik selama satu menit
+	served := func(role string) int {
+		now := time.Now()
+		clock := func() time.Time { return now }
+
+		global := NewTokenBucketLimiter(config.RateLimitGlobal(), 10)
+		global.now = clock
+		tier := NewTokenBucketLimiter(QuotaFor(role).RateLimitPolicy(role), 10)
+		tier.now = clock
+
+		count := 0
+		for i := 0; i < 20*60; i++ {
+			now = now.Add(50 * time.Millisecond)
+			result, _ := global.Allow(context.Background(), "ip:1")
+			if !result.Allowed {
+				continue
+			}
+			if result, _ = tier.Allow(context.Background(), "user:1"); result.Allowed {
+				count++
+			}
+		}
+		return count
+	}
+
+	assert.LessOrEqual(t, served(RoleUser), QuotaFor(RoleUser).RequestsPerMinute+QuotaFor(RoleUser).Burst)
+	// Premium mendapat burst penuh di atas 300 request per menit
+	premium := QuotaFor(RolePremium)
+	assert.Greater(t, served(RolePremium), 300)
+	assert.GreaterOrEqual(t, served(RolePremium), premium.RequestsPerMinute+premium.Burst-1)
+	assert.Greater(t, served(RoleAdmin), served(RolePremium))
+	assert.GreaterOrEqual(t, served(RoleAdmin), QuotaFor(RoleAdmin).RequestsPerMinute)
+}
+
+// TestQuotaDay tests that daily quotas follow the UTC calendar day
+func TestQuotaDay(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	now := time.Date(2025, 1, 31, 5, 0, 0, 0, jakarta) // 30 Jan 22:00 UTC
+
+	assert.Equal(t, time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC), QuotaDay(now))
+	assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), QuotaResetAt(now))
+}
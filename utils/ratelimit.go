@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"final/config"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitResult hasil pengecekan rate limit untuk satu request
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // kapasitas bucket
+	Remaining  int           // sisa request yang boleh dikirim saat ini
+	RetryAfter time.Duration // waktu tunggu sampai request berikutnya diizinkan, 0 jika Allowed
+}
+
+// Limiter membatasi jumlah request per kunci (IP, user, API key)
+type Limiter interface {
+	// Allow mengambil satu kuota untuk kunci dan mengembalikan sisa kuota
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// bucketEntry satu bucket di dalam LRU
+type bucketEntry struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+// TokenBucketLimiter menyimpan satu token bucket per kunci (IP, user, API key)
+// Jumlah bucket dibatasi dengan LRU agar memori tidak tumbuh tanpa batas
+// saat banyak klien berbeda mengirim request
+type TokenBucketLimiter struct {
+	mutex   sync.Mutex
+	policy  config.RateLimitPolicy
+	maxKeys int
+	order   *list.List // depan = paling baru dipakai
+	buckets map[string]*list.Element
+	now     func() time.Time
+}
+
+// NewTokenBucketLimiter membuat limiter in-memory dengan maksimum maxKeys bucket
+func NewTokenBucketLimiter(policy config.RateLimitPolicy, maxKeys int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		policy:  policy,
+		maxKeys: maxKeys,
+		order:   list.New(),
+		buckets: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// bucket mengambil bucket kunci atau membuat yang baru, dipanggil dengan mutex terkunci
+func (l *TokenBucketLimiter) bucket(key string) *rate.Limiter {
+	if elem, ok := l.buckets[key]; ok {
+		l.order.MoveToFront(elem)
+		return elem.Value.(*bucketEntry).limiter
+	}
+
+	every := rate.Every(l.policy.Period / time.Duration(l.policy.Rate))
+	limiter := rate.NewLimiter(every, l.policy.Burst)
+	l.buckets[key] = l.order.PushFront(&bucketEntry{key: key, limiter: limiter})
+
+	// Buang bucket yang paling lama tidak dipakai
+	for l.order.Len() > l.maxKeys {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*bucketEntry).key)
+	}
+	return limiter
+}
+
+// Allow mengambil satu token dari bucket kunci
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	limiter := l.bucket(key)
+	result := RateLimitResult{Limit: l.policy.Burst}
+
+	if limiter.AllowN(now, 1) {
+		result.Allowed = true
+		result.Remaining = int(math.Floor(limiter.TokensAt(now)))
+		return result, nil
+	}
+
+	// Waktu sampai satu token terisi kembali
+	missing := 1 - limiter.TokensAt(now)
+	result.RetryAfter = time.Duration(missing / float64(limiter.Limit()) * float64(time.Second))
+	return result, nil
+}
+
+// Len mengembalikan jumlah bucket yang sedang disimpan
+func (l *TokenBucketLimiter) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.order.Len()
+}
+
+// gcraScript menjalankan GCRA (generic cell rate algorithm) secara atomik di Redis
+// Yang disimpan per kunci hanya TAT (theoretical arrival time) dalam mikrodetik
+// KEYS[1] = kunci, ARGV = interval per request, burst, waktu sekarang
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+if allow_at > now then
+	return {0, 0, allow_at - now}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / emission), 0}
+`)
+
+// RedisLimiter implementasi Limiter dengan GCRA di Redis
+// Semua replika memakai kuota yang sama karena state disimpan di Redis
+type RedisLimiter struct {
+	client  *redis.Client
+	policy  config.RateLimitPolicy
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewRedisLimiter membuat limiter yang menyimpan state di Redis
+func NewRedisLimiter(client *redis.Client, policy config.RateLimitPolicy) *RedisLimiter {
+	return &RedisLimiter{
+		client:  client,
+		policy:  policy,
+		timeout: config.RateLimitRedisTimeout(),
+		now:     time.Now,
+	}
+}
+
+// Allow mengambil satu kuota untuk kunci dari Redis
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	emission := (l.policy.Period / time.Duration(l.policy.Rate)).Microseconds()
+	res, err := gcraScript.Run(ctx, l.client,
+		[]string{"ratelimit:" + l.policy.Name + ":" + key},
+		emission, l.policy.Burst, l.now().UnixMicro()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      l.policy.Burst,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+	}, nil
+}
+
+// FallbackLimiter memakai limiter utama (Redis) dan beralih ke limiter lokal
+// selama limiter utama error, sehingga API tetap terlindungi saat Redis mati
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	degraded atomic.Bool
+}
+
+// NewFallbackLimiter membuat limiter dengan cadangan lokal
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback}
+}
+
+// Allow mencoba limiter utama lalu limiter cadangan jika gagal
+func (l *FallbackLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	result, err := l.primary.Allow(ctx, key)
+	if err == nil {
+		if l.degraded.CompareAndSwap(true, false) {
+			log.Println("Rate limiter kembali memakai store bersama")
+		}
+		return result, nil
+	}
+
+	// Log hanya saat berpindah agar log tidak dibanjiri setiap request
+	if l.degraded.CompareAndSwap(false, true) {
+		log.Printf("Warning: store rate limiter tidak bisa dihubungi, memakai limiter lokal: %v", err)
+	}
+	return l.fallback.Allow(ctx, key)
+}
+
+// NewRateLimiter membuat Limiter sesuai RATE_LIMIT_STORE
+// Backend redis selalu dibungkus dengan limiter lokal sebagai cadangan
+func NewRateLimiter(policy config.RateLimitPolicy) Limiter {
+	local := NewTokenBucketLimiter(policy, config.RateLimitMaxKeys())
+	if config.RateLimitBackend() != "redis" || config.Redis == nil {
+		return local
+	}
+	return NewFallbackLimiter(NewRedisLimiter(config.Redis, policy), local)
+}
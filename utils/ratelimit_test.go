@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"final/config"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// allow memanggil limiter dan memastikan tidak ada error
+func allow(t *testing.T, limiter Limiter, key string) RateLimitResult {
+	t.Helper()
+	result, err := limiter.Allow(context.Background(), key)
+	assert.Nil(t, err)
+	return result
+}
+
+// TestTokenBucketLimiter tests burst, remaining count and retry-after per key
+func TestTokenBucketLimiter(t *testing.T) {
+	limiter := NewTokenBucketLimiter(config.RateLimitPolicy{Rate: 1, Period: time.Minute, Burst: 3}, 100)
+
+	for i := 2; i >= 0; i-- {
+		result := allow(t, limiter, "ip:1")
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result := allow(t, limiter, "ip:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.InDelta(t, time.Minute.Seconds(), result.RetryAfter.Seconds(), 1)
+
+	// Kunci lain memiliki bucket sendiri
+	assert.True(t, allow(t, limiter, "ip:2").Allowed)
+}
+
+// TestTokenBucketLimiterEviction tests that the least recently used bucket is evicted
+func TestTokenBucketLimiterEviction(t *testing.T) {
+	limiter := NewTokenBucketLimiter(config.RateLimitPolicy{Rate: 1, Period: time.Minute, Burst: 1}, 2)
+
+	allow(t, limiter, "a")
+	allow(t, limiter, "b")
+	allow(t, limiter, "a") // a menjadi yang paling baru dipakai
+	allow(t, limiter, "c") // b dibuang
+
+	assert.Equal(t, 2, limiter.Len())
+	assert.True(t, allow(t, limiter, "b").Allowed, "bucket b dibuat ulang setelah dibuang")
+	assert.False(t, allow(t, limiter, "c").Allowed)
+}
+
+// TestRedisLimiter tests GCRA limiting against an in-process Redis
+func TestRedisLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	now := time.Now()
+	limiter := NewRedisLimiter(client, config.RateLimitPolicy{Name: "test", Rate: 1, Period: time.Second, Burst: 2})
+	limiter.now = func() time.Time { return now }
+
+	result := allow(t, limiter, "ip:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+
+	result = allow(t, limiter, "ip:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result = allow(t, limiter, "ip:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Limiter di replika lain memakai kuota yang sama
+	other := NewRedisLimiter(client, config.RateLimitPolicy{Name: "test", Rate: 1, Period: time.Second, Burst: 2})
+	other.now = limiter.now
+	assert.False(t, allow(t, other, "ip:1").Allowed)
+
+	// Satu token terisi kembali setelah satu periode
+	now = now.Add(time.Second)
+	assert.True(t, allow(t, limiter, "ip:1").Allowed)
+	assert.True(t, allow(t, limiter, "ip:2").Allowed)
+	assert.True(t, server.Exists("ratelimit:test:ip:1"))
+}
+
+// TestFallbackLimiter tests that limiting continues locally while Redis is unreachable
+func TestFallbackLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	policy := config.RateLimitPolicy{Name: "test", Rate: 1, Period: time.Minute, Burst: 1}
+
+	limiter := NewFallbackLimiter(NewRedisLimiter(client, policy), NewTokenBucketLimiter(policy, 100))
+	assert.True(t, allow(t, limiter, "ip:1").Allowed)
+
+	server.Close()
+	assert.True(t, allow(t, limiter, "ip:2").Allowed)
+	assert.False(t, allow(t, limiter, "ip:2").Allowed, "limiter lokal tetap membatasi")
+	assert.True(t, limiter.degraded.Load())
+}
@@ -0,0 +1,68 @@
+package utils
+
+// Role yang dikenal aplikasi, disimpan di kolom users.role
+const (
+	RoleUser    = "user"
+	RolePremium = "premium"
+	RoleAdmin   = "admin"
+)
+
+// Permission adalah nama izin untuk sebuah aksi
+type Permission string
+
+// Daftar permission yang digunakan oleh route
+const (
+	PermPostsWrite    Permission = "posts:write"
+	PermPostsModerate Permission = "posts:moderate"
+	PermUsersRead     Permission = "users:read"
+	PermUsersManage   Permission = "users:manage"
+	PermUpload        Permission = "uploads:write"
+	PermCacheManage   Permission = "cache:manage"
+	// PermUsersImpersonate boleh masuk sebagai user lain untuk membantu support
+	PermUsersImpersonate Permission = "users:impersonate"
+)
+
+// rolePermissions memetakan setiap role ke permission yang dimilikinya
+var rolePermissions = map[string][]Permission{
+	RoleUser: {
+		PermPostsWrite,
+		PermUsersRead,
+		PermUpload,
+	},
+	// Premium memiliki akses yang sama dengan user, bedanya hanya kuota
+	RolePremium: {
+		PermPostsWrite,
+		PermUsersRead,
+		PermUpload,
+	},
+	RoleAdmin: {
+		PermPostsWrite,
+		PermPostsModerate,
+		PermUsersRead,
+		PermUsersManage,
+		PermUpload,
+		PermCacheManage,
+		PermUsersImpersonate,
+	},
+}
+
+// IsValidRole mengecek apakah role dikenal
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsFor mengembalikan daftar permission untuk role
+func PermissionsFor(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// HasPermission mengecek apakah role memiliki permission tertentu
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"final/config"
+	"final/models"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore menyimpan daftar jti token yang sudah dicabut (blacklist)
+type RevocationStore interface {
+	// Revoke mencabut token dengan jti tertentu sampai waktu expiresAt
+	Revoke(jti string, expiresAt time.Time) error
+	// IsRevoked mengecek apakah jti sudah dicabut
+	IsRevoked(jti string) (bool, error)
+	// Prune menghapus entri yang tokennya sudah kedaluwarsa
+	Prune() error
+}
+
+// MemoryRevocationStore implementasi RevocationStore di memori (untuk satu instance/testing)
+type MemoryRevocationStore struct {
+	mutex   sync.RWMutex
+	entries map[string]time.Time
+}
+
+// NewMemoryRevocationStore membuat RevocationStore di memori
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{entries: make(map[string]time.Time)}
+}
+
+// Revoke menandai jti sebagai dicabut
+func (s *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	s.entries[jti] = expiresAt
+	s.mutex.Unlock()
+	return nil
+}
+
+// IsRevoked mengecek jti di memori, entri yang sudah kedaluwarsa dianggap tidak ada
+func (s *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mutex.RLock()
+	expiresAt, exists := s.entries[jti]
+	s.mutex.RUnlock()
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+// Prune menghapus entri yang sudah kedaluwarsa
+func (s *MemoryRevocationStore) Prune() error {
+	now := time.Now()
+	s.mutex.Lock()
+	for jti, expiresAt := range s.entries {
+		if !now.Before(expiresAt) {
+			delete(s.entries, jti)
+		}
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+// DBRevocationStore implementasi RevocationStore di PostgreSQL
+// sehingga pencabutan tetap berlaku setelah restart dan di semua replika
+type DBRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewDBRevocationStore membuat RevocationStore yang disimpan di database
+func NewDBRevocationStore(db *gorm.DB) *DBRevocationStore {
+	return &DBRevocationStore{db: db}
+}
+
+// Revoke menyimpan jti ke tabel revoked_tokens
+func (s *DBRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "jti"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+	}).Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsRevoked mengecek apakah jti ada di tabel dan belum kedaluwarsa
+func (s *DBRevocationStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Prune menghapus baris yang tokennya sudah kedaluwarsa
+func (s *DBRevocationStore) Prune() error {
+	return s.db.Where("expires_at <= ?", time.Now()).Delete(&models.RevokedToken{}).Error
+}
+
+// Store yang digunakan aplikasi, default di memori
+var (
+	revocationStore RevocationStore = NewMemoryRevocationStore()
+	revocationMutex sync.RWMutex
+)
+
+// SetRevocationStore mengganti store pencabutan token yang digunakan
+func SetRevocationStore(store RevocationStore) {
+	revocationMutex.Lock()
+	revocationStore = store
+	revocationMutex.Unlock()
+}
+
+// GetRevocationStore mengembalikan store pencabutan token yang aktif
+func GetRevocationStore() RevocationStore {
+	revocationMutex.RLock()
+	defer revocationMutex.RUnlock()
+	return revocationStore
+}
+
+// RevokeToken mencabut token berdasarkan claims jti dan exp
+func RevokeToken(claims *jwt.MapClaims) error {
+	jti, ok := (*claims)["jti"].(string)
+	if !ok || jti == "" {
+		return errors.New("token tidak memiliki jti")
+	}
+
+	expiresAt := time.Now().Add(time.Hour * time.Duration(config.JWTRefreshExpiryTime()))
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
+
+	return GetRevocationStore().Revoke(jti, expiresAt)
+}
+
+// IsTokenRevoked mengecek apakah token sudah dicabut
+// Token tanpa jti (dibuat sebelum fitur ini ada) dianggap dicabut
+func IsTokenRevoked(claims *jwt.MapClaims) (bool, error) {
+	jti, ok := (*claims)["jti"].(string)
+	if !ok || jti == "" {
+		return true, nil
+	}
+	return GetRevocationStore().IsRevoked(jti)
+}
+
+// StartRevocationPruner menjalankan goroutine untuk membersihkan entri kedaluwarsa
+// secara berkala sampai ctx dibatalkan
+func StartRevocationPruner(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := GetRevocationStore().Prune(); err != nil {
+					log.Printf("Gagal membersihkan token yang dicabut: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryRevocationStore tests revoke, lookup and pruning of expired entries
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+
+	assert.Nil(t, store.Revoke("active", time.Now().Add(time.Hour)))
+	assert.Nil(t, store.Revoke("expired", time.Now().Add(-time.Minute)))
+
+	revoked, err := store.IsRevoked("active")
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+
+	// Entri yang sudah kedaluwarsa tidak lagi dianggap dicabut
+	revoked, _ = store.IsRevoked("expired")
+	assert.False(t, revoked)
+
+	revoked, _ = store.IsRevoked("unknown")
+	assert.False(t, revoked)
+
+	assert.Nil(t, store.Prune())
+	assert.Len(t, store.entries, 1)
+}
+
+// TestRevokeGeneratedToken tests that a revoked access token is reported as revoked
+func TestRevokeGeneratedToken(t *testing.T) {
+	SetRevocationStore(NewMemoryRevocationStore())
+
+	tokens, err := GenerateJWT(TokenSubject{UserID: 1, FamilyID: "family-1"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, tokens.AccessUUID)
+	assert.NotEqual(t, tokens.AccessUUID, tokens.RefreshUUID)
+
+	claims, err := ParseJWT(tokens.AccessToken)
+	assert.Nil(t, err)
+
+	revoked, err := IsTokenRevoked(claims)
+	assert.Nil(t, err)
+	assert.False(t, revoked)
+
+	assert.Nil(t, RevokeToken(claims))
+
+	revoked, err = IsTokenRevoked(claims)
+	assert.Nil(t, err)
+	assert.True(t, revoked)
+
+	// Refresh token dengan jti berbeda tidak ikut dicabut
+	refreshClaims, err := ParseRefreshToken(tokens.RefreshToken)
+	assert.Nil(t, err)
+	revoked, _ = IsTokenRevoked(refreshClaims)
+	assert.False(t, revoked)
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// VerifiedToken adalah hasil verifikasi access token yang disimpan di TokenCache
+// Data user tidak ikut disimpan karena dimuat ulang di setiap request
+type VerifiedToken struct {
+	UserID       uint
+	TokenVersion uint
+	Claims       *jwt.MapClaims
+}
+
+// tokenCacheEntry adalah satu token di cache, verified nil berarti token tidak valid
+type tokenCacheEntry struct {
+	key       string
+	verified  *VerifiedToken
+	expiresAt time.Time
+}
+
+// TokenCache menyimpan hasil verifikasi JWT agar signature tidak dicek ulang di setiap request
+// Dibatasi jumlah entry (LRU), entry dibuang saat token kedaluwarsa, dan bisa dihapus
+// per token atau per user. Token disimpan dalam bentuk hash
+type TokenCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	order      *list.List // depan = paling baru dipakai
+	entries    map[string]*list.Element
+	users      map[string]map[string]struct{}
+}
+
+// NewTokenCache membuat cache verifikasi token dengan batas jumlah entry
+func NewTokenCache(maxEntries int) *TokenCache {
+	return &TokenCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		users:      make(map[string]map[string]struct{}),
+	}
+}
+
+// Get mengembalikan hasil verifikasi token yang belum kedaluwarsa
+// found true dengan verified nil berarti token sebelumnya gagal diverifikasi
+func (tc *TokenCache) Get(token string) (verified *VerifiedToken, found bool) {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	key := HashToken(token)
+	elem, ok := tc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*tokenCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		tc.remove(key)
+		return nil, false
+	}
+	tc.order.MoveToFront(elem)
+	return entry.verified, true
+}
+
+// Set menyimpan token yang valid sampai expiresAt (claim exp)
+func (tc *TokenCache) Set(token string, verified VerifiedToken, expiresAt time.Time) {
+	tc.set(HashToken(token), &verified, expiresAt)
+}
+
+// SetInvalid menyimpan token yang gagal diverifikasi selama ttl
+func (tc *TokenCache) SetInvalid(token string, ttl time.Duration) {
+	tc.set(HashToken(token), nil, time.Now().Add(ttl))
+}
+
+// set menyimpan entry lalu membuang entry lama sampai batas terpenuhi
+func (tc *TokenCache) set(key string, verified *VerifiedToken, expiresAt time.Time) {
+	if !time.Now().Before(expiresAt) {
+		return
+	}
+
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	tc.remove(key)
+	tc.entries[key] = tc.order.PushFront(&tokenCacheEntry{key: key, verified: verified, expiresAt: expiresAt})
+	if verified != nil {
+		addToIndex(tc.users, userIndexKey(verified.UserID), key)
+	}
+	for tc.order.Len() > tc.maxEntries {
+		tc.remove(tc.order.Back().Value.(*tokenCacheEntry).key)
+	}
+}
+
+// InvalidateToken menghapus satu token dari cache sehingga request berikutnya diverifikasi ulang
+func (tc *TokenCache) InvalidateToken(token string) bool {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	key := HashToken(token)
+	_, ok := tc.entries[key]
+	tc.remove(key)
+	return ok
+}
+
+// InvalidateUser menghapus semua token milik user dan mengembalikan jumlah yang dihapus
+func (tc *TokenCache) InvalidateUser(userID uint) int {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	index := tc.users[userIndexKey(userID)]
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		tc.remove(key)
+	}
+	return len(keys)
+}
+
+// Len mengembalikan jumlah entry di cache
+func (tc *TokenCache) Len() int {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+	return tc.order.Len()
+}
+
+// Prune menghapus entry yang sudah kedaluwarsa
+func (tc *TokenCache) Prune() {
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	now := time.Now()
+	for key, elem := range tc.entries {
+		if !now.Before(elem.Value.(*tokenCacheEntry).expiresAt) {
+			tc.remove(key)
+		}
+	}
+}
+
+// Start menjalankan goroutine untuk membersihkan entry kedaluwarsa
+// secara berkala sampai ctx dibatalkan
+func (tc *TokenCache) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tc.Prune()
+			}
+		}
+	}()
+}
+
+// remove menghapus entry beserta indeks user, dipanggil dengan mutex terkunci
+func (tc *TokenCache) remove(key string) {
+	elem, ok := tc.entries[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*tokenCacheEntry)
+
+	tc.order.Remove(elem)
+	delete(tc.entries, key)
+	if entry.verified != nil {
+		removeFromIndex(tc.users, userIndexKey(entry.verified.UserID), key)
+	}
+}
+
+// userIndexKey adalah kunci indeks token per user
+func userIndexKey(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// Cache verifikasi token yang dibagi oleh semua AuthMiddleware
+var (
+	tokenCache      = NewTokenCache(10000)
+	tokenCacheMutex sync.RWMutex
+)
+
+// SetTokenCache mengganti cache verifikasi token yang digunakan
+func SetTokenCache(cache *TokenCache) {
+	tokenCacheMutex.Lock()
+	tokenCache = cache
+	tokenCacheMutex.Unlock()
+}
+
+// GetTokenCache mengembalikan cache verifikasi token yang aktif
+func GetTokenCache() *TokenCache {
+	tokenCacheMutex.RLock()
+	defer tokenCacheMutex.RUnlock()
+	return tokenCache
+}
+
+// InvalidateCachedToken menghapus token dari cache verifikasi, contoh setelah logout
+func InvalidateCachedToken(token string) {
+	GetTokenCache().InvalidateToken(token)
+}
+
+// InvalidateCachedUserTokens menghapus semua token user dari cache verifikasi,
+// contoh setelah token version dinaikkan atau user dihapus
+func InvalidateCachedUserTokens(userID uint) {
+	GetTokenCache().InvalidateUser(userID)
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTokenCache tests lookup, expiry and invalidation by token and by user
+func TestTokenCache(t *testing.T) {
+	cache := NewTokenCache(100)
+	later := time.Now().Add(time.Hour)
+
+	cache.Set("alice-1", VerifiedToken{UserID: 1}, later)
+	cache.Set("alice-2", VerifiedToken{UserID: 1}, later)
+	cache.Set("bob-1", VerifiedToken{UserID: 2}, later)
+	cache.SetInvalid("garbage", time.Minute)
+
+	verified, found := cache.Get("bob-1")
+	assert.True(t, found)
+	assert.Equal(t, uint(2), verified.UserID)
+
+	// Token tidak valid tersimpan tanpa hasil verifikasi
+	verified, found = cache.Get("garbage")
+	assert.True(t, found)
+	assert.Nil(t, verified)
+
+	// Token disimpan dalam bentuk hash
+	_, ok := cache.entries["bob-1"]
+	assert.False(t, ok)
+
+	assert.Equal(t, 2, cache.InvalidateUser(1))
+	_, found = cache.Get("alice-1")
+	assert.False(t, found)
+
+	assert.True(t, cache.InvalidateToken("bob-1"))
+	assert.False(t, cache.InvalidateToken("bob-1"))
+	assert.Equal(t, 1, cache.Len())
+
+	// Token yang sudah kedaluwarsa tidak dikembalikan lagi
+	cache.Set("short", VerifiedToken{UserID: 3}, time.Now().Add(20*time.Millisecond))
+	_, found = cache.Get("short")
+	assert.True(t, found)
+	time.Sleep(30 * time.Millisecond)
+	_, found = cache.Get("short")
+	assert.False(t, found)
+
+	cache.Set("expired", VerifiedToken{UserID: 3}, time.Now().Add(-time.Second))
+	_, found = cache.Get("expired")
+	assert.False(t, found)
+}
+
+// TestTokenCacheBounded tests that the least recently used token is evicted at capacity
+func TestTokenCacheBounded(t *testing.T) {
+	cache := NewTokenCache(3)
+	later := time.Now().Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		cache.Set(fmt.Sprint(i), VerifiedToken{UserID: 1}, later)
+	}
+
+	// 0 dipakai sehingga 1 menjadi yang paling lama tidak dipakai
+	cache.Get("0")
+	cache.SetInvalid("3", time.Minute)
+
+	assert.Equal(t, 3, cache.Len())
+	_, found := cache.Get("1")
+	assert.False(t, found)
+	_, found = cache.Get("0")
+	assert.True(t, found)
+
+	// Indeks user ikut dibersihkan saat token dibuang
+	assert.Equal(t, 2, cache.InvalidateUser(1))
+}
+
+// TestTokenCachePrune tests that the cleanup goroutine prunes expired entries until ctx is cancelled
+func TestTokenCachePrune(t *testing.T) {
+	cache := NewTokenCache(10)
+	cache.Set("short", VerifiedToken{UserID: 1}, time.Now().Add(10*time.Millisecond))
+	cache.Set("long", VerifiedToken{UserID: 1}, time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Start(ctx, 5*time.Millisecond)
+
+	assert.Eventually(t, func() bool { return cache.Len() == 1 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"final/models"
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenUnknown refresh token tidak tercatat di database
+	ErrRefreshTokenUnknown = errors.New("refresh token tidak dikenal")
+	// ErrRefreshTokenReused refresh token sudah pernah dipakai (indikasi token bocor)
+	ErrRefreshTokenReused = errors.New("refresh token sudah pernah digunakan")
+	// ErrTokenFamilyRevoked family dari refresh token sudah dicabut
+	ErrTokenFamilyRevoked = errors.New("token family sudah dicabut")
+)
+
+// SessionInfo adalah perangkat dan alamat IP yang dicatat saat login
+type SessionInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// maxUserAgentLength adalah panjang kolom token_families.user_agent
+const maxUserAgentLength = 255
+
+// CreateTokenFamily membuat token family baru (sesi) untuk satu kali login
+func CreateTokenFamily(db *gorm.DB, userID uint, session SessionInfo) (*models.TokenFamily, error) {
+	userAgent := session.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	family := &models.TokenFamily{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		UserAgent: userAgent,
+		IP:        session.IP,
+	}
+	if err := db.Create(family).Error; err != nil {
+		return nil, err
+	}
+	return family, nil
+}
+
+// RecordRefreshToken mencatat refresh token yang baru diterbitkan ke dalam family
+func RecordRefreshToken(db *gorm.DB, familyID string, td *TokenDetails) error {
+	return db.Create(&models.RefreshToken{
+		JTI:       td.RefreshUUID,
+		FamilyID:  familyID,
+		AccessJTI: td.AccessUUID,
+		ExpiresAt: time.Unix(td.RtExpires, 0),
+	}).Error
+}
+
+// ConsumeRefreshToken menandai refresh token sudah dipakai
+// Jika token sudah pernah dipakai, seluruh family dicabut dan ErrRefreshTokenReused dikembalikan
+func ConsumeRefreshToken(db *gorm.DB, claims *jwt.MapClaims) (*models.TokenFamily, error) {
+	jti, _ := (*claims)["jti"].(string)
+	familyID, _ := (*claims)["fam"].(string)
+
+	var family models.TokenFamily
+	reused := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris token agar dua request refresh bersamaan tidak sama-sama lolos
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("jti = ? AND family_id = ?", jti, familyID).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenUnknown
+			}
+			return err
+		}
+
+		if err := tx.First(&family, "id = ?", familyID).Error; err != nil {
+			return err
+		}
+		if family.RevokedAt != nil {
+			return ErrTokenFamilyRevoked
+		}
+
+		if token.ConsumedAt != nil {
+			reused = true
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&token).Update("consumed_at", &now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&family).Update("last_refreshed_at", &now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		log.Printf("[SECURITY] Refresh token dipakai ulang: user_id=%d family=%s jti=%s, seluruh family dicabut",
+			family.UserID, family.ID, jti)
+		if err := RevokeTokenFamily(db, family.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return &family, nil
+}
+
+// RevokeTokenFamily mencabut family beserta semua access dan refresh token yang masih berlaku di dalamnya
+func RevokeTokenFamily(db *gorm.DB, familyID string) error {
+	now := time.Now()
+	if err := db.Model(&models.TokenFamily{}).
+		Where("id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", &now).Error; err != nil {
+		return err
+	}
+
+	var tokens []models.RefreshToken
+	if err := db.Where("family_id = ? AND expires_at > ?", familyID, now).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	store := GetRevocationStore()
+	for _, token := range tokens {
+		if err := store.Revoke(token.JTI, token.ExpiresAt); err != nil {
+			return err
+		}
+		// Access token selalu lebih cepat kedaluwarsa dari refresh token pasangannya
+		if token.AccessJTI != "" {
+			if err := store.Revoke(token.AccessJTI, token.ExpiresAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RevokeUserTokenFamilies mencabut semua token family milik user (logout dari semua perangkat)
+func RevokeUserTokenFamilies(db *gorm.DB, userID uint) error {
+	_, err := RevokeOtherTokenFamilies(db, userID, "")
+	return err
+}
+
+// RevokeOtherTokenFamilies mencabut semua token family milik user kecuali keepID
+// (logout dari perangkat lain) dan mengembalikan jumlah family yang dicabut
+func RevokeOtherTokenFamilies(db *gorm.DB, userID uint, keepID string) (int, error) {
+	var families []models.TokenFamily
+	if err := db.Where("user_id = ? AND revoked_at IS NULL AND id <> ?", userID, keepID).Find(&families).Error; err != nil {
+		return 0, err
+	}
+
+	for i, family := range families {
+		if err := RevokeTokenFamily(db, family.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(families), nil
+}
+
+// ActiveTokenFamilies mengembalikan sesi user yang belum dicabut dan masih bisa di-refresh,
+// diurutkan dari yang terbaru
+func ActiveTokenFamilies(db *gorm.DB, userID uint) ([]models.TokenFamily, error) {
+	var families []models.TokenFamily
+	err := db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.family_id = token_families.id AND refresh_tokens.consumed_at IS NULL AND refresh_tokens.expires_at > ?)", time.Now()).
+		Order("created_at DESC").
+		Find(&families).Error
+	return families, err
+}
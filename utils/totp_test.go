@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the ASCII key "12345678901234567890" from RFC 6238 appendix B, base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCodeRFC6238 tests the SHA1 test vectors from RFC 6238 (last 6 digits)
+func TestTOTPCodeRFC6238(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+// TestValidateTOTPSkew tests that adjacent steps are accepted and distant ones are not
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok := ValidateTOTP(rfc6238Secret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	_, ok = ValidateTOTP(rfc6238Secret, "050471", now.Add(30*time.Second))
+	assert.True(t, ok)
+
+	_, ok = ValidateTOTP(rfc6238Secret, "050471", now.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(rfc6238Secret, "12345", now)
+	assert.False(t, ok)
+}
+
+// TestTOTPAuthURI tests the otpauth URI format
+func TestTOTPAuthURI(t *testing.T) {
+	uri := TOTPAuthURI("Final Project API", "johndoe", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Final%20Project%20API:johndoe?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+}
+
+// TestEncryptSecret tests that secrets round-trip and are not stored in plaintext
+func TestEncryptSecret(t *testing.T) {
+	encrypted, err := EncryptSecret(rfc6238Secret)
+	assert.Nil(t, err)
+	assert.NotContains(t, encrypted, rfc6238Secret)
+
+	decrypted, err := DecryptSecret(encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, rfc6238Secret, decrypted)
+
+	_, err = DecryptSecret(encrypted[:len(encrypted)-4] + "AAAA")
+	assert.NotNil(t, err)
+}